@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/service"
+)
+
+// subscriberBuffer is how many messages a slow subscriber may lag behind before messages are dropped for it
+const subscriberBuffer = 256
+
+// streamKey identifies the messages of a topic of a connection, several connections may consume the same topic.
+type streamKey struct {
+	connection *service.KafkaOBJ
+	topic      string
+}
+
+// Hub broadcasts the decoded messages of every connection to its subscribers.
+// A subscriber that can't keep up misses messages instead of slowing down the consumption.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[streamKey]map[chan []byte]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[streamKey]map[chan []byte]struct{}, 0)}
+}
+
+// Subscribe returns the JSON encoded messages of the topic of the connection until unsubscribe is called.
+func (h *Hub) Subscribe(k *service.KafkaOBJ, topic string) (<-chan []byte, func()) {
+	key := streamKey{connection: k, topic: topic}
+	channel := make(chan []byte, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[chan []byte]struct{}, 0)
+	}
+
+	h.subscribers[key][channel] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[key], channel)
+		if len(h.subscribers[key]) == 0 {
+			delete(h.subscribers, key)
+		}
+	}
+
+	return channel, unsubscribe
+}
+
+// Sink returns the message sink broadcasting the messages of the connection.
+func (h *Hub) Sink(k *service.KafkaOBJ) service.MessageSink {
+	return &hubSink{hub: h, connection: k}
+}
+
+func (h *Hub) broadcast(key streamKey, message models.Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subscribers[key]) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(service.NewWriterRecord(message))
+	if err != nil {
+		return err
+	}
+
+	for channel := range h.subscribers[key] {
+		select {
+		case channel <- data:
+		default:
+		}
+	}
+
+	return nil
+}
+
+type hubSink struct {
+	hub        *Hub
+	connection *service.KafkaOBJ
+}
+
+func (s *hubSink) Message(message models.Message, stats service.DecodeStats) error {
+	return s.hub.broadcast(streamKey{connection: s.connection, topic: message.Topic}, message)
+}
+
+func (s *hubSink) Log(topic, text string) {}
+
+func (s *hubSink) Error(topic string, err error) {}
@@ -0,0 +1,502 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/krogertechnology/data-tracker/datastore/sqlite"
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/service"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+const maxRecordsLimit = 1000
+
+// ConnectFunc connects to a cluster the same way the Add Eventhub form does.
+type ConnectFunc func(config models.Config) (*service.KafkaOBJ, error)
+
+// Server exposes the connections managed by data-tracker and the stored records to local tools.
+//
+//	GET  /api/connections                              list the connections
+//	POST /api/connections                              connect with a config in the import file format
+//	GET  /api/connections/{id}/topics/{topic}/stream   WebSocket streaming the decoded messages of a topic
+//	GET  /api/records                                  query the stored records
+//
+// Only the requests addressed to localhost, 127.0.0.1 or [::1] are served so a
+// page of another site can't reach the API by rebinding its domain to 127.0.0.1.
+type Server struct {
+	Store   *sqlite.Store
+	Hub     *Hub
+	Connect ConnectFunc
+
+	port string // Expected in the Host header once ListenAndServe is called, any port when empty
+
+	mu          sync.Mutex
+	connections map[int]*service.KafkaOBJ
+	nextID      int
+}
+
+func NewServer(store *sqlite.Store, hub *Hub, connect ConnectFunc) *Server {
+	return &Server{
+		Store:       store,
+		Hub:         hub,
+		Connect:     connect,
+		connections: make(map[int]*service.KafkaOBJ, 0),
+		nextID:      1,
+	}
+}
+
+// Register makes a connection visible to the API, including the ones created from the GUI.
+func (s *Server) Register(k *service.KafkaOBJ) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID += 1
+	s.connections[id] = k
+
+	return id
+}
+
+// ListenAndServe serves the API on a loopback address, the API has no authentication
+// so it refuses to listen on an address reachable from other machines.
+func (s *Server) ListenAndServe(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid API address %s: %v", addr, err)
+	}
+
+	if !isLoopback(host) {
+		return fmt.Errorf("the API only listens on localhost, %s is not a loopback address", host)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// The port is only known once listening when it was 0
+	_, s.port, err = net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	return http.Serve(listener, s.Handler())
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/connections", s.handleConnections)
+	mux.HandleFunc("/api/connections/", s.handleConnection)
+	mux.HandleFunc("/api/records", s.handleRecords)
+
+	return s.allowLocalHosts(allowLocalOrigins(mux))
+}
+
+// allowLocalHosts rejects the requests whose Host header isn't a local name with the port of the API.
+func (s *Server) allowLocalHosts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, port, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host, port = r.Host, "80"
+		}
+
+		if !isLocalHost(host) || (s.port != "" && port != s.port) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %s is not allowed, use localhost", r.Host))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+type topicResponse struct {
+	DataFormat  string `json:"data_format"`
+	Compression string `json:"compression"`
+	Decoded     int    `json:"decoded"`
+	Failed      int    `json:"failed"`
+	LastError   string `json:"last_error,omitempty"`
+	Stream      string `json:"stream"`
+}
+
+// connectionResponse leaves out the credentials of the connection.
+type connectionResponse struct {
+	ID            int                      `json:"id"`
+	Hosts         string                   `json:"hosts"`
+	SASLMechanism string                   `json:"sasl_mechanism"`
+	ConsumerGroup string                   `json:"consumer_group,omitempty"`
+	Paused        bool                     `json:"paused"`
+	Topics        map[string]topicResponse `json:"topics"`
+}
+
+func newConnectionResponse(id int, k *service.KafkaOBJ) connectionResponse {
+	response := connectionResponse{
+		ID:            id,
+		Hosts:         k.Configs.KAFKA_HOSTS,
+		SASLMechanism: k.Configs.KAFKA_SASL_MECHANISM,
+		ConsumerGroup: k.Configs.KAFKA_CONSUMER_GROUP_ID,
+		Paused:        k.Paused(),
+		Topics:        make(map[string]topicResponse, 0),
+	}
+
+	for _, topic := range k.Configs.TOPICS {
+		stats := k.DecodeStats(topic)
+
+		response.Topics[topic] = topicResponse{
+			DataFormat:  k.DataFormat(topic),
+			Compression: k.Compression(topic),
+			Decoded:     stats.Decoded,
+			Failed:      stats.Failed,
+			LastError:   stats.LastError,
+			Stream:      fmt.Sprintf("/api/connections/%d/topics/%s/stream", id, url.PathEscape(topic)),
+		}
+	}
+
+	return response
+}
+
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.listConnections())
+
+	case http.MethodPost:
+		// A JSON body can't be sent cross-site without a preflight, which is only allowed for local origins
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("the body must be application/json"))
+			return
+		}
+
+		var config models.Config
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&config)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid connection config: %v", err))
+			return
+		}
+
+		err = utils.ApplyConnectionString(&config)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		k, err := s.Connect(config)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+
+		id := s.Register(k)
+
+		writeJSON(w, http.StatusCreated, newConnectionResponse(id, k))
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	}
+}
+
+// listConnections returns the open connections ordered by ID, the disconnected ones are forgotten.
+func (s *Server) listConnections() []connectionResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connections := make([]connectionResponse, 0)
+
+	for id := 1; id < s.nextID; id++ {
+		k, ok := s.connections[id]
+		if !ok {
+			continue
+		}
+
+		if k.Disconnected() {
+			delete(s.connections, id)
+			continue
+		}
+
+		connections = append(connections, newConnectionResponse(id, k))
+	}
+
+	return connections
+}
+
+type recordResponse struct {
+	ID int64 `json:"id"`
+	service.WriterRecord
+}
+
+// handleRecords queries the stored records with the same filters as the History tab
+// eg: /api/records?topic=orders&since=2024-05-01T00:00:00Z&search=$.order.storeId = '0042'
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	filter, err := parseRecordFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := s.Store.Search(r.URL.Query().Get("search"), filter)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response := make([]recordResponse, 0, len(records))
+	for _, record := range records {
+		message := models.Message{
+			Topic:       record.Topic,
+			Partition:   record.Partition,
+			Offset:      record.Offset,
+			Key:         record.Key,
+			Headers:     record.Headers,
+			Value:       []byte(record.Message),
+			Timestamp:   record.Timestamp,
+			DecodeError: record.DecodeError,
+		}
+
+		if record.DecodeError != "" {
+			message.Value = record.Raw
+		} else if !json.Valid(message.Value) {
+			// Records stored before the messages were validated are returned as a JSON string
+			message.Value, _ = json.Marshal(record.Message)
+		}
+
+		response = append(response, recordResponse{ID: record.ID, WriterRecord: service.NewWriterRecord(message)})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func parseRecordFilter(query url.Values) (sqlite.RecordFilter, error) {
+	filter := sqlite.RecordFilter{Topic: query.Get("topic")}
+
+	if value := query.Get("partition"); value != "" {
+		partition, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid partition %s", value)
+		}
+
+		p := int32(partition)
+		filter.Partition = &p
+	}
+
+	for name, field := range map[string]**int64{"from_offset": &filter.FromOffset, "to_offset": &filter.ToOffset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %s", name, value)
+		}
+
+		*field = &offset
+	}
+
+	for name, field := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %s, expected an RFC 3339 timestamp", name, value)
+		}
+
+		*field = t
+	}
+
+	for name, field := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s %s", name, value)
+		}
+
+		*field = n
+	}
+
+	if filter.Limit > maxRecordsLimit {
+		filter.Limit = maxRecordsLimit
+	}
+
+	return filter, nil
+}
+
+// connection returns the open connection registered with the ID.
+func (s *Server) connection(id int) (*service.KafkaOBJ, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.connections[id]
+	if !ok || k.Disconnected() {
+		return nil, false
+	}
+
+	return k, true
+}
+
+// handleConnection serves /api/connections/{id}/topics/{topic}/stream.
+func (s *Server) handleConnection(w http.ResponseWriter, r *http.Request) {
+	notFound := errors.New("not found, expected /api/connections/{id}/topics/{topic}/stream")
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/connections/"), "/")
+	if len(parts) != 4 || parts[1] != "topics" || parts[2] == "" || parts[3] != "stream" {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+
+	topic, err := url.PathUnescape(parts[2])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid topic %s", parts[2]))
+		return
+	}
+
+	k, ok := s.connection(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("connection %d does not exist", id))
+		return
+	}
+
+	if _, ok := k.DataChannel[topic]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("connection %d does not consume topic %s", id, topic))
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: checkWebSocketOrigin,
+		Handler: func(ws *websocket.Conn) {
+			s.stream(ws, k, topic)
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// stream sends the messages of the topic until the client goes away.
+func (s *Server) stream(ws *websocket.Conn, k *service.KafkaOBJ, topic string) {
+	defer ws.Close()
+
+	messages, unsubscribe := s.Hub.Subscribe(k, topic)
+	defer unsubscribe()
+
+	// Nothing is expected from the client, reading only notices when it closes the socket
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		var ignored string
+		for websocket.Message.Receive(ws, &ignored) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case data := <-messages:
+			err := websocket.Message.Send(ws, string(data))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkWebSocketOrigin only lets local pages and clients without an Origin such as scripts open a stream.
+func checkWebSocketOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin != "" && !isLocalOrigin(origin) {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+
+	return nil
+}
+
+// allowLocalOrigins lets the pages served from localhost call the API.
+func allowLocalOrigins(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && isLocalOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLocalOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return isLoopback(u.Hostname())
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("error writing API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/service"
+)
+
+func newTestConnection(topic string) *service.KafkaOBJ {
+	k := service.NewKafkaObj(models.Config{KAFKA_HOSTS: "localhost:9092", KAFKA_TOPIC: topic})
+	k.DataChannel[topic] = make(chan models.Message)
+
+	return k
+}
+
+func TestHandlerRejectsOtherHosts(t *testing.T) {
+	server := NewServer(nil, NewHub(), nil)
+	server.port = "8765"
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{host: "localhost:8765", want: http.StatusOK},
+		{host: "127.0.0.1:8765", want: http.StatusOK},
+		{host: "[::1]:8765", want: http.StatusOK},
+		{host: "localhost:8080", want: http.StatusForbidden},
+		{host: "localhost", want: http.StatusForbidden},
+		{host: "attacker.example:8765", want: http.StatusForbidden},
+		{host: "127.0.0.1.nip.io:8765", want: http.StatusForbidden},
+		{host: "127.0.0.2:8765", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/connections", nil)
+		req.Host = tt.host
+
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("Host %s got status %d, want %d", tt.host, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestStreamIsPerConnection(t *testing.T) {
+	hub := NewHub()
+	server := NewServer(nil, hub, nil)
+
+	first, second := newTestConnection("orders"), newTestConnection("orders")
+	firstID := server.Register(first)
+	server.Register(second)
+
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	var connections []connectionResponse
+
+	resp, err := http.Get(httpServer.URL + "/api/connections")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&connections)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := connections[0].Topics["orders"].Stream
+	if stream != "/api/connections/1/topics/orders/stream" || connections[0].ID != firstID {
+		t.Fatalf("connection %d streams from %s", connections[0].ID, stream)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + stream
+
+	ws, err := websocket.Dial(wsURL, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ws.Close()
+
+	// The subscription is registered by the handler after the handshake
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		hub.mu.Lock()
+		subscribed := len(hub.subscribers[streamKey{connection: first, topic: "orders"}]) > 0
+		hub.mu.Unlock()
+
+		if subscribed {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the stream never subscribed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hub.Sink(second).Message(models.Message{Topic: "orders", Offset: 1, Value: []byte(`{"from":"second"}`)}, service.DecodeStats{})
+	hub.Sink(first).Message(models.Message{Topic: "orders", Offset: 2, Value: []byte(`{"from":"first"}`)}, service.DecodeStats{})
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var record service.WriterRecord
+
+	err = websocket.JSON.Receive(ws, &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Offset != 2 || string(record.Value) != `{"from":"first"}` {
+		t.Errorf("received offset %d with %s, want only the messages of the first connection", record.Offset, record.Value)
+	}
+
+	for _, path := range []string{"/api/connections/9/topics/orders/stream", "/api/connections/1/topics/payments/stream", "/api/topics/orders/stream"} {
+		resp, err := http.Get(httpServer.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s got status %d, want %d", path, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
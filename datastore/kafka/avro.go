@@ -1,77 +1,244 @@
 package kafka
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"os"
+	"sync"
 
 	"github.com/krogertechnology/data-tracker/models"
 	"github.com/linkedin/goavro"
 )
 
-type AvroConfig models.AvroConfig
+type AvroConfig struct {
+	*models.AvroConfig
+	Registry *SchemaRegistry
+
+	localOnce  sync.Once
+	localCodec *goavro.Codec // Built from SCHEMA_FILE
+	localErr   error
+
+	subjectMu     sync.Mutex        // Only a resolved subject is kept, a failure is retried on the next message
+	subjectSchema *RegisteredSchema // SCHEMA_VERSION of SCHEMA_SUBJECT
+	subjectCodec  *goavro.Codec
+}
+
+func NewAvroConfig(config *models.AvroConfig) *AvroConfig {
+	if config == nil {
+		return nil
+	}
+
+	avroConfig := &AvroConfig{AvroConfig: config}
+	if config.SCHEMA_URL != "" {
+		avroConfig.Registry = NewSchemaRegistry(config)
+	}
+
+	return avroConfig
+}
 
 func (a *AvroConfig) ProcessAvroMessage(msg models.Message) (*models.Message, error) {
-	if a == nil || a.SCHEMA_URL == "" {
+	if a == nil || a.Registry == nil {
 		return nil, errors.New("no scema found for the data")
 	}
 
 	value := msg.Value
+
+	// Confluent wire format: magic byte 0, 4 bytes schema ID, then the Avro payload
+	if len(value) < 5 {
+		return nil, fmt.Errorf("payload of %d bytes is too short for the Confluent Avro wire format", len(value))
+	}
+
+	if value[0] != 0 {
+		return nil, fmt.Errorf("unknown magic byte 0x%02x, expected 0x00 for the Confluent Avro wire format", value[0])
+	}
+
 	schemaID := binary.BigEndian.Uint32(value[1:5])
 
-	finalMsg := models.Message{
-		Topic:     msg.Topic,
-		Partition: msg.Partition,
-		Offset:    msg.Offset,
-		Value:     msg.Value[5:],
-		Headers:   msg.Headers,
+	finalMsg := msg
+	finalMsg.Value = msg.Value[5:]
+
+	codec, err := a.Registry.Codec(int(schemaID))
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := codec.NativeFromBinary(msg.Value[5:])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Avro data with schema %d: %v", schemaID, err)
 	}
 
-	resp, err := http.Get(fmt.Sprintf("%s/schemas/ids/%d", a.SCHEMA_URL, schemaID))
+	finalMsg.Value, err = json.Marshal(native)
 	if err != nil {
 		return nil, err
 	}
 
-	respData, err := io.ReadAll(resp.Body)
+	return &finalMsg, nil
+}
+
+// LoadSchemaFile builds the codec of the local schema file once per connection.
+func (a *AvroConfig) LoadSchemaFile() error {
+	if a.SCHEMA_FILE == "" {
+		return errors.New("a local Avro schema file is required")
+	}
+
+	a.localOnce.Do(func() {
+		schema, err := os.ReadFile(a.SCHEMA_FILE)
+		if err != nil {
+			a.localErr = fmt.Errorf("error reading Avro schema file: %v", err)
+			return
+		}
+
+		a.localCodec, err = goavro.NewCodec(string(schema))
+		if err != nil {
+			a.localErr = fmt.Errorf("error creating Avro codec from %s: %v", a.SCHEMA_FILE, err)
+		}
+	})
+
+	return a.localErr
+}
+
+// ProcessLocalAvroMessage decodes a raw Avro payload, without the Confluent framing, with the local schema.
+func (a *AvroConfig) ProcessLocalAvroMessage(msg models.Message) (*models.Message, error) {
+	err := a.LoadSchemaFile()
 	if err != nil {
 		return nil, err
 	}
 
-	schemaResp := struct {
-		SchemaID string
-		Schema   string
-	}{}
+	native, rest, err := a.localCodec.NativeFromBinary(msg.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Avro data with %s: %v", a.SCHEMA_FILE, err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d bytes left after decoding Avro data with %s", len(rest), a.SCHEMA_FILE)
+	}
 
-	err = json.Unmarshal(respData, &schemaResp)
+	finalMsg := msg
+	finalMsg.Value, err = json.Marshal(native)
 	if err != nil {
 		return nil, err
 	}
 
-	schema := make(map[string]interface{}, 0)
-	err = json.Unmarshal([]byte(schemaResp.Schema), &schema)
+	return &finalMsg, nil
+}
+
+// LoadSubject resolves the pinned version of the subject once per connection.
+func (a *AvroConfig) LoadSubject() error {
+	_, _, err := a.subject()
+	return err
+}
+
+func (a *AvroConfig) subject() (*RegisteredSchema, *goavro.Codec, error) {
+	if a.Registry == nil || a.SCHEMA_SUBJECT == "" {
+		return nil, nil, errors.New("a schema registry URL and subject are required")
+	}
+
+	a.subjectMu.Lock()
+	defer a.subjectMu.Unlock()
+
+	if a.subjectCodec != nil {
+		return a.subjectSchema, a.subjectCodec, nil
+	}
+
+	version := a.SCHEMA_VERSION
+	if version == "" {
+		version = "latest"
+	}
+
+	schema, err := a.Registry.SubjectVersion(a.SCHEMA_SUBJECT, version, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codec, err := a.Registry.CodecFor(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a.subjectSchema, a.subjectCodec = schema, codec
+
+	return schema, codec, nil
+}
+
+// ProcessSubjectAvroMessage decodes with the pinned subject version. Framed messages written
+// with another schema are rejected, unframed ones are decoded as raw Avro.
+func (a *AvroConfig) ProcessSubjectAvroMessage(msg models.Message) (*models.Message, error) {
+	schema, codec, err := a.subject()
+	if err != nil {
+		return nil, err
+	}
+
+	value := msg.Value
+
+	if len(value) >= 5 && value[0] == 0 {
+		schemaID := int(binary.BigEndian.Uint32(value[1:5]))
+		if schemaID != schema.ID {
+			return nil, fmt.Errorf("message was written with schema %d, expected version %d of %s (schema %d)", schemaID, schema.Version, a.SCHEMA_SUBJECT, schema.ID)
+		}
+
+		value = value[5:]
+	}
+
+	native, rest, err := codec.NativeFromBinary(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Avro data with version %d of %s: %v", schema.Version, a.SCHEMA_SUBJECT, err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d bytes left after decoding Avro data with version %d of %s", len(rest), schema.Version, a.SCHEMA_SUBJECT)
+	}
+
+	finalMsg := msg
+	finalMsg.Value, err = json.Marshal(native)
 	if err != nil {
 		return nil, err
 	}
 
-	schemaBytes, _ := json.Marshal(schema)
+	return &finalMsg, nil
+}
+
+// EncodeAvroMessage encodes a body in the Avro JSON encoding, the format shown for decoded messages,
+// with a version of the subject and frames it in the Confluent wire format like the registry serializers.
+func (a *AvroConfig) EncodeAvroMessage(subject, version string, body []byte) ([]byte, error) {
+	if a == nil || a.Registry == nil {
+		return nil, errors.New("a schema registry URL is required to encode Avro")
+	}
+
+	if version == "" {
+		version = "latest"
+	}
 
-	codec, err := goavro.NewCodec(string(schemaBytes))
+	schema, err := a.Registry.SubjectVersion(subject, version, false)
 	if err != nil {
-		log.Fatalf("Error creating Avro codec: %v", err)
 		return nil, err
 	}
 
-	native, _, err := codec.NativeFromBinary(msg.Value[5:])
+	codec, err := a.Registry.CodecFor(schema)
 	if err != nil {
-		log.Fatalf("Error decoding Avro data: %v", err)
 		return nil, err
 	}
 
-	finalMsg.Value, _ = json.Marshal(native)
+	// goavro doesn't skip the leading whitespace
+	native, rest, err := codec.NativeFromTextual(bytes.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("the body does not match version %d of subject %s: %v", schema.Version, subject, err)
+	}
+
+	// A second document after the first one would be silently dropped
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected data after the body: %.40s", rest)
+	}
+
+	value := make([]byte, 5)
+	binary.BigEndian.PutUint32(value[1:], uint32(schema.ID))
+
+	value, err = codec.BinaryFromNative(value, native)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding Avro data with schema %d: %v", schema.ID, err)
+	}
 
-	return &finalMsg, err
+	return value, nil
 }
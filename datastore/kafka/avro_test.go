@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// newTestRegistry serves version 1 of the orders-value subject with an int schema,
+// the first fail requests are answered with a 404.
+func newTestRegistry(t *testing.T, fail int32) (*AvroConfig, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= fail {
+			http.Error(w, `{"error_code":40401}`, http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{"subject":"orders-value","version":1,"id":7,"schema":"\"int\""}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := NewAvroConfig(&models.AvroConfig{
+		SCHEMA_URL:       server.URL,
+		SCHEMA_SUBJECT:   "orders-value",
+		SCHEMA_CACHE_DIR: "-",
+	})
+
+	return config, &requests
+}
+
+func TestLoadSubjectRetriesFailures(t *testing.T) {
+	config, requests := newTestRegistry(t, 1)
+
+	// Failures are otherwise returned again until the TTL passes
+	config.Registry.failureTTL = 0
+
+	if config.LoadSubject() == nil {
+		t.Fatal("LoadSubject succeeded while the registry failed")
+	}
+
+	err := config.LoadSubject()
+	if err != nil {
+		t.Fatalf("LoadSubject after the registry recovered: %v", err)
+	}
+
+	err = config.LoadSubject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("registry got %d requests, want 2 since only the success is kept", got)
+	}
+
+	msg, err := config.ProcessSubjectAvroMessage(models.Message{Value: []byte{0, 0, 0, 0, 7, 0x02}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(msg.Value) != "1" {
+		t.Errorf("decoded %s, want 1", msg.Value)
+	}
+}
+
+func TestEncodeAvroMessage(t *testing.T) {
+	config, _ := newTestRegistry(t, 0)
+
+	tests := []struct {
+		body    string
+		want    []byte
+		wantErr bool
+	}{
+		{body: "1", want: []byte{0, 0, 0, 0, 7, 0x02}},
+		{body: " 1 \n", want: []byte{0, 0, 0, 0, 7, 0x02}},
+		{body: "1 2", wantErr: true},
+		{body: `"1"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := config.EncodeAvroMessage("orders-value", "", []byte(tt.body))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("EncodeAvroMessage(%q) error = %v, wantErr %v", tt.body, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && string(got) != string(tt.want) {
+			t.Errorf("EncodeAvroMessage(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
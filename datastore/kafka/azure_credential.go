@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	AzureClientSecret      = "CLIENT_SECRET"
+	AzureClientCertificate = "CLIENT_CERTIFICATE"
+	AzureCLI               = "AZURE_CLI"
+	AzureManagedIdentity   = "MANAGED_IDENTITY"
+	AzureWorkloadIdentity  = "WORKLOAD_IDENTITY"
+	AzureDefault           = "DEFAULT"
+)
+
+// AzureCredentialTypes lists the values accepted in AAD_CREDENTIAL_TYPE.
+var AzureCredentialTypes = []string{AzureClientSecret, AzureClientCertificate, AzureCLI, AzureManagedIdentity, AzureWorkloadIdentity, AzureDefault}
+
+const (
+	AzureCloudPublic       = "PUBLIC"
+	AzureCloudUSGovernment = "US_GOVERNMENT"
+	AzureCloudChina        = "CHINA"
+)
+
+// AzureClouds lists the values accepted in AAD_CLOUD.
+var AzureClouds = []string{AzureCloudPublic, AzureCloudUSGovernment, AzureCloudChina}
+
+// azureCloud returns the cloud the tokens are requested from, the public cloud when none is set.
+func (k *KafkaConfig) azureCloud() (cloud.Configuration, error) {
+	switch k.AAD_CLOUD {
+	case "", AzureCloudPublic:
+		return cloud.AzurePublic, nil
+	case AzureCloudUSGovernment:
+		return cloud.AzureGovernment, nil
+	case AzureCloudChina:
+		return cloud.AzureChina, nil
+	}
+
+	return cloud.Configuration{}, fmt.Errorf("unsupported azure cloud: %v", k.AAD_CLOUD)
+}
+
+func (k *KafkaConfig) azureCredentialType() string {
+	if k.AAD_CREDENTIAL_TYPE == "" {
+		return AzureClientSecret
+	}
+
+	return k.AAD_CREDENTIAL_TYPE
+}
+
+// validateAzureConfig checks the fields required by the selected credential type.
+func (k *KafkaConfig) validateAzureConfig() error {
+	if k.AzureConfig == nil {
+		return errors.New("azure Configs are not provided")
+	}
+
+	if k.AAD_AUDIENCE == "" {
+		return errors.New("missing azure configs: AAD_AUDIENCE is required")
+	}
+
+	_, err := k.azureCloud()
+	if err != nil {
+		return err
+	}
+
+	switch k.azureCredentialType() {
+	case AzureClientSecret:
+		if k.AAD_APPLICATION_ID == "" || k.AAD_APPLICATION_SECRET == "" || k.AAD_TENANT_ID == "" {
+			return errors.New("missing azure configs: AAD_TENANT_ID, AAD_APPLICATION_ID and AAD_APPLICATION_SECRET are required")
+		}
+
+	case AzureClientCertificate:
+		if k.AAD_APPLICATION_ID == "" || k.AAD_CERTIFICATE_PATH == "" || k.AAD_TENANT_ID == "" {
+			return errors.New("missing azure configs: AAD_TENANT_ID, AAD_APPLICATION_ID and AAD_CERTIFICATE_PATH are required")
+		}
+
+	case AzureCLI, AzureManagedIdentity, AzureWorkloadIdentity, AzureDefault:
+		// Anything not provided is read from the environment
+
+	default:
+		return fmt.Errorf("unsupported azure credential type: %v", k.AAD_CREDENTIAL_TYPE)
+	}
+
+	return nil
+}
+
+// newAzureCredential builds the credential of the selected type.
+func (k *KafkaConfig) newAzureCredential(clientOpts azcore.ClientOptions) (azcore.TokenCredential, error) {
+	switch k.azureCredentialType() {
+	case AzureClientCertificate:
+		data, err := os.ReadFile(k.AAD_CERTIFICATE_PATH)
+		if err != nil {
+			return nil, fmt.Errorf("error reading azure certificate: %v", err)
+		}
+
+		certs, key, err := azidentity.ParseCertificates(data, []byte(k.AAD_CERTIFICATE_PASSWORD))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing azure certificate: %v", err)
+		}
+
+		return azidentity.NewClientCertificateCredential(k.AAD_TENANT_ID, k.AAD_APPLICATION_ID, certs, key,
+			&azidentity.ClientCertificateCredentialOptions{ClientOptions: clientOpts})
+
+	case AzureCLI:
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: k.AAD_TENANT_ID})
+
+	case AzureManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOpts}
+		if k.AAD_MANAGED_IDENTITY_CLIENT_ID != "" {
+			opts.ID = azidentity.ClientID(k.AAD_MANAGED_IDENTITY_CLIENT_ID)
+		}
+
+		return azidentity.NewManagedIdentityCredential(opts)
+
+	case AzureWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOpts,
+			TenantID:      k.AAD_TENANT_ID,
+			ClientID:      k.AAD_APPLICATION_ID,
+			TokenFilePath: k.AAD_FEDERATED_TOKEN_FILE,
+		})
+
+	case AzureDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOpts,
+			TenantID:      k.AAD_TENANT_ID,
+		})
+	}
+
+	return azidentity.NewClientSecretCredential(k.AAD_TENANT_ID, k.AAD_APPLICATION_ID, k.AAD_APPLICATION_SECRET,
+		&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOpts})
+}
@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+const (
+	eventHubsDomain    = ".servicebus.windows.net"
+	eventHubsKafkaPort = "9093"
+)
+
+// ParseBrokers splits KAFKA_HOSTS into host:port addresses. Event Hubs
+// namespaces get the Kafka endpoint port when none is given.
+func ParseBrokers(hosts string) ([]string, error) {
+	brokers := make([]string, 0)
+
+	for _, broker := range utils.GetElementsFromString(hosts) {
+		if broker == "" {
+			continue
+		}
+
+		if !strings.Contains(broker, ":") && strings.HasSuffix(strings.ToLower(broker), eventHubsDomain) {
+			broker = net.JoinHostPort(broker, eventHubsKafkaPort)
+		}
+
+		host, port, err := net.SplitHostPort(broker)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid kafka host %q, expected host:port", broker)
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("invalid port in kafka host %q", broker)
+		}
+
+		brokers = append(brokers, broker)
+	}
+
+	if len(brokers) == 0 {
+		return nil, errors.New("no kafka hosts provided")
+	}
+
+	return brokers, nil
+}
@@ -0,0 +1,202 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	CompressionAuto   = "AUTO"
+	CompressionNone   = "NONE"
+	CompressionGzip   = "GZIP"
+	CompressionZstd   = "ZSTD"
+	CompressionSnappy = "SNAPPY"
+	CompressionLZ4    = "LZ4"
+)
+
+// Compressions lists the values accepted in COMPRESSION and TOPIC_COMPRESSIONS.
+var Compressions = []string{CompressionAuto, CompressionNone, CompressionGzip, CompressionZstd, CompressionSnappy, CompressionLZ4}
+
+// maxDecompressedSize guards against payloads that expand without limit
+const maxDecompressedSize = 64 << 20
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4Magic          = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyFramedMagic = []byte("\xff\x06\x00\x00sNaPpY")
+	snappyXerialMagic = []byte("\x82SNAPPY\x00")
+)
+
+// zstdDecoder is safe for concurrent use and expensive to create
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+
+// TopicCompression returns the compression selected for the topic, or the one of the connection.
+func (k *KafkaConfig) TopicCompression(topic string) string {
+	compression, ok := k.TOPIC_COMPRESSIONS[topic]
+	if ok && compression != "" {
+		return strings.ToUpper(compression)
+	}
+
+	if k.COMPRESSION == "" {
+		return CompressionAuto
+	}
+
+	return strings.ToUpper(k.COMPRESSION)
+}
+
+func ValidateCompression(compression string) error {
+	for _, c := range Compressions {
+		if strings.EqualFold(c, compression) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown compression %s, expected one of %s", compression, strings.Join(Compressions, ", "))
+}
+
+// DetectCompression recognizes the compressions by their magic bytes, raw snappy blocks have none.
+func DetectCompression(value []byte) string {
+	switch {
+	case bytes.HasPrefix(value, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(value, zstdMagic):
+		return CompressionZstd
+	case bytes.HasPrefix(value, lz4Magic):
+		return CompressionLZ4
+	case bytes.HasPrefix(value, snappyFramedMagic), bytes.HasPrefix(value, snappyXerialMagic):
+		return CompressionSnappy
+	}
+
+	return CompressionNone
+}
+
+// Decompress unwraps a payload compressed by the producer, on top of the batch compression done by Kafka.
+// With AUTO the payload is returned as is when no compression is detected, or when the detected one
+// fails since an uncompressed payload may start with the same bytes.
+func Decompress(value []byte, compression string) ([]byte, error) {
+	compression = strings.ToUpper(compression)
+	if compression == "" || compression == CompressionAuto {
+		data, err := decompress(value, DetectCompression(value))
+		if err != nil {
+			return value, nil
+		}
+
+		return data, nil
+	}
+
+	return decompress(value, compression)
+}
+
+func decompress(value []byte, compression string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch compression {
+	case CompressionNone:
+		return value, nil
+
+	case CompressionGzip:
+		var reader *gzip.Reader
+
+		reader, err = gzip.NewReader(bytes.NewReader(value))
+		if err == nil {
+			data, err = readLimited(reader)
+		}
+
+	case CompressionZstd:
+		data, err = zstdDecoder.DecodeAll(value, nil)
+
+	case CompressionLZ4:
+		data, err = readLimited(lz4.NewReader(bytes.NewReader(value)))
+
+	case CompressionSnappy:
+		if bytes.HasPrefix(value, snappyFramedMagic) {
+			data, err = readLimited(snappy.NewReader(bytes.NewReader(value)))
+		} else if bytes.HasPrefix(value, snappyXerialMagic) {
+			data, err = decodeXerialSnappy(value)
+		} else {
+			data, err = decodeSnappyBlock(value, 0)
+		}
+
+	default:
+		return nil, ValidateCompression(compression)
+	}
+
+	if errors.Is(err, errTooLarge) || len(data) > maxDecompressedSize {
+		return nil, fmt.Errorf("%s payload is larger than %d MiB once decompressed", compression, maxDecompressedSize>>20)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing %s payload: %v", compression, err)
+	}
+
+	return data, nil
+}
+
+var errTooLarge = errors.New("payload is too large once decompressed")
+
+// decodeSnappyBlock decodes a raw block, its declared length is checked before
+// it is allocated since the block is added to the size already decompressed.
+func decodeSnappyBlock(block []byte, decompressed int) ([]byte, error) {
+	size, err := snappy.DecodedLen(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if decompressed+size > maxDecompressedSize {
+		return nil, errTooLarge
+	}
+
+	return snappy.Decode(nil, block)
+}
+
+// decodeXerialSnappy decodes the snappy-java stream format: the magic bytes,
+// a version and a compatible version, then blocks prefixed by their big endian length.
+func decodeXerialSnappy(value []byte) ([]byte, error) {
+	const headerSize = 16
+
+	if len(value) < headerSize {
+		return nil, errors.New("the xerial snappy header is truncated")
+	}
+
+	data := make([]byte, 0)
+
+	for pos := headerSize; pos < len(value); {
+		if len(value)-pos < 4 {
+			return nil, errors.New("the xerial snappy block length is truncated")
+		}
+
+		size := int(binary.BigEndian.Uint32(value[pos:]))
+		pos += 4
+
+		if size > len(value)-pos {
+			return nil, fmt.Errorf("the xerial snappy block of %d bytes is truncated", size)
+		}
+
+		block, err := decodeSnappyBlock(value[pos:pos+size], len(data))
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, block...)
+		pos += size
+	}
+
+	return data, nil
+}
+
+func readLimited(reader io.Reader) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+}
@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, data []byte) []byte {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer encoder.Close()
+
+	return encoder.EncodeAll(data, nil)
+}
+
+func lz4Compressed(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+
+	writer := lz4.NewWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+func snappyFramed(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+
+	writer := snappy.NewBufferedWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+// xerialFramed frames raw snappy blocks like snappy-java, the version fields are not checked.
+func xerialFramed(blocks ...[]byte) []byte {
+	data := append([]byte{}, snappyXerialMagic...)
+	data = binary.BigEndian.AppendUint32(data, 1)
+	data = binary.BigEndian.AppendUint32(data, 1)
+
+	for _, block := range blocks {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(block)))
+		data = append(data, block...)
+	}
+
+	return data
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(`{"order":{"storeId":"0042"}}`)
+
+	// A raw block declaring a length above the limit, the data is never reached
+	bomb := binary.AppendUvarint(nil, maxDecompressedSize+1)
+
+	garbledGzip := append(append([]byte{}, gzipMagic...), "not gzip"...)
+
+	tests := []struct {
+		name        string
+		value       []byte
+		compression string
+		want        []byte
+		wantErr     bool
+	}{
+		{name: "auto without compression", value: payload, compression: "", want: payload},
+		{name: "none", value: payload, compression: CompressionNone, want: payload},
+		{name: "auto gzip", value: gzipped(t, payload), compression: CompressionAuto, want: payload},
+		{name: "gzip", value: gzipped(t, payload), compression: "gzip", want: payload},
+		{name: "auto zstd", value: zstdCompressed(t, payload), compression: CompressionAuto, want: payload},
+		{name: "auto lz4", value: lz4Compressed(t, payload), compression: CompressionAuto, want: payload},
+		{name: "auto framed snappy", value: snappyFramed(t, payload), compression: CompressionAuto, want: payload},
+		{name: "auto xerial snappy", value: xerialFramed(snappy.Encode(nil, payload[:10]), snappy.Encode(nil, payload[10:])), compression: CompressionAuto, want: payload},
+		{name: "raw snappy", value: snappy.Encode(nil, payload), compression: CompressionSnappy, want: payload},
+		{name: "auto keeps a payload that only looks compressed", value: garbledGzip, compression: CompressionAuto, want: garbledGzip},
+		{name: "selected compression that fails", value: garbledGzip, compression: CompressionGzip, wantErr: true},
+		{name: "raw snappy too large", value: bomb, compression: CompressionSnappy, wantErr: true},
+		{name: "xerial snappy too large", value: xerialFramed(bomb), compression: CompressionSnappy, wantErr: true},
+		{name: "xerial snappy truncated", value: xerialFramed(snappy.Encode(nil, payload))[:30], compression: CompressionSnappy, wantErr: true},
+		{name: "unknown", value: payload, compression: "BROTLI", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decompress(tt.value, tt.compression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decompress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("Decompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
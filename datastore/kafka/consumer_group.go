@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/IBM/sarama"
@@ -9,39 +10,94 @@ import (
 
 type ConsumerHandler struct {
 	ChannelMap map[string]chan models.Message
+	Configs    *KafkaConfig
+	WhenPaused func(pause func()) // See KafkaConsumer.WhenPaused
+	ctx        context.Context    // Cancelled on disconnect, unlike the session context which ends on every rebalance
+	group      sarama.ConsumerGroup
+	seeked     map[string]map[int32]bool
 }
 
-func (c *ConsumerHandler) Setup(sarama.ConsumerGroupSession) error {
+func (c *ConsumerHandler) Setup(session sarama.ConsumerGroupSession) error {
+	c.seekOffsets(session)
+
 	log := "Successfully setup consumer group session\n"
 	for k := range c.ChannelMap {
-		c.ChannelMap[k] <- models.Message{Logs: log}
+		send(c.ctx, c.ChannelMap[k], models.Message{Logs: log})
 	}
 
 	return nil
 }
 
-func (c *ConsumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
+// seekOffsets moves the group to the resolved offsets the first time a partition is claimed.
+// Oldest and latest are handled by Consumer.Offsets.Initial and keep the committed offsets.
+func (c *ConsumerHandler) seekOffsets(session sarama.ConsumerGroupSession) {
+	if c.Configs == nil || c.Configs.OFFSET_SPEC.Kind == OffsetOldest || c.Configs.OFFSET_SPEC.Kind == OffsetLatest {
+		return
+	}
+
+	if c.seeked == nil {
+		c.seeked = make(map[string]map[int32]bool, 0)
+	}
+
+	for topic, partitions := range session.Claims() {
+		if c.seeked[topic] == nil {
+			c.seeked[topic] = make(map[int32]bool, 0)
+		}
+
+		for _, partition := range partitions {
+			if c.seeked[topic][partition] {
+				continue
+			}
+
+			offset := c.Configs.PartitionOffset(topic, partition)
+			if offset < 0 {
+				continue
+			}
+
+			// ResetOffset only moves backwards and MarkOffset only forwards
+			session.ResetOffset(topic, partition, offset, "")
+			session.MarkOffset(topic, partition, offset, "")
+
+			c.seeked[topic][partition] = true
+		}
+	}
+}
+
+func (c *ConsumerHandler) Cleanup(session sarama.ConsumerGroupSession) error {
 	log := "Exiting consumer group session"
 	for k := range c.ChannelMap {
-		c.ChannelMap[k] <- models.Message{Logs: log}
+		send(c.ctx, c.ChannelMap[k], models.Message{Logs: log})
 	}
 
 	return nil
 }
 
 func (c *ConsumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	// Every rebalance starts new partition consumers which don't inherit the pause
+	if c.WhenPaused != nil && c.group != nil {
+		c.WhenPaused(func() {
+			c.group.Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+		})
+	}
+
 	for msg := range claim.Messages() {
 		headers := convertSaramaHeaderToMap(msg.Headers)
 		data := models.Message{
-			Headers:   headers,
-			Offset:    msg.Offset,
-			Topic:     msg.Topic,
-			Partition: msg.Partition,
-			Value:     msg.Value,
-			Logs:      fmt.Sprintf("Consumed from topic %v, Partition %v with Offset %v\n", msg.Topic, msg.Partition, msg.Offset),
+			Headers:        headers,
+			Key:            msg.Key,
+			Offset:         msg.Offset,
+			Topic:          msg.Topic,
+			Partition:      msg.Partition,
+			Value:          msg.Value,
+			Timestamp:      msg.Timestamp,
+			BlockTimestamp: msg.BlockTimestamp,
+			Logs:           fmt.Sprintf("Consumed from topic %v, Partition %v with Offset %v\n", msg.Topic, msg.Partition, msg.Offset),
+		}
+
+		if !send(c.ctx, c.ChannelMap[msg.Topic], data) {
+			return nil
 		}
 
-		c.ChannelMap[msg.Topic] <- data
 		session.MarkMessage(msg, "")
 	}
 
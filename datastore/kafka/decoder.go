@@ -0,0 +1,192 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// Decoder turns a message payload into JSON.
+type Decoder interface {
+	// Sniff reports whether the payload looks like this format, it is only used in AUTO mode
+	Sniff(value []byte) bool
+	Decode(msg models.Message) (*models.Message, error)
+}
+
+// fallbackDecoder is implemented by the decoders whose Sniff can't recognize their format,
+// AUTO only tries them after every other decoder including TEXT.
+type fallbackDecoder interface {
+	Fallback() bool
+}
+
+func isFallback(decoder Decoder) bool {
+	fallback, ok := decoder.(fallbackDecoder)
+
+	return ok && fallback.Fallback()
+}
+
+// DecoderFactory builds the decoder from the connection configs, it returns an
+// error when the configs needed by the format are missing.
+type DecoderFactory func(k *KafkaConfig) (Decoder, error)
+
+const DecoderAuto = "AUTO"
+
+var (
+	decodersMu sync.Mutex
+	decoders   = make(map[string]DecoderFactory, 0)
+
+	// Registration order, it is also the order in which AUTO tries the decoders apart from the fallbacks
+	decoderNames = make([]string, 0)
+)
+
+// RegisterDecoder makes a format available by name, registering a name twice replaces the factory.
+func RegisterDecoder(name string, factory DecoderFactory) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	name = strings.ToUpper(name)
+
+	_, ok := decoders[name]
+	if !ok {
+		decoderNames = append(decoderNames, name)
+	}
+
+	decoders[name] = factory
+}
+
+// DecoderNames lists AUTO followed by the registered formats.
+func DecoderNames() []string {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	return append([]string{DecoderAuto}, decoderNames...)
+}
+
+// NewDecoder builds the decoder registered with the name, an empty name selects AUTO.
+// AUTO tries JSON and then the schema registry AVRO first so an empty DATA_FORMAT
+// still decodes the topics that were read before the formats were selectable.
+func (k *KafkaConfig) NewDecoder(name string) (Decoder, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" || name == DecoderAuto {
+		return k.newAutoDecoder(), nil
+	}
+
+	decodersMu.Lock()
+	factory, ok := decoders[name]
+	decodersMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown data format %s, expected one of %s", name, strings.Join(DecoderNames(), ", "))
+	}
+
+	decoder, err := factory(k)
+	if err != nil {
+		return nil, fmt.Errorf("data format %s: %v", name, err)
+	}
+
+	return decoder, nil
+}
+
+// TopicDataFormat returns the format selected for the topic, or the one of the connection.
+func (k *KafkaConfig) TopicDataFormat(topic string) string {
+	format, ok := k.TOPIC_DATA_FORMATS[topic]
+	if ok && format != "" {
+		return strings.ToUpper(format)
+	}
+
+	if k.DATA_FORMAT == "" {
+		return DecoderAuto
+	}
+
+	return strings.ToUpper(k.DATA_FORMAT)
+}
+
+type namedDecoder struct {
+	name string
+	Decoder
+}
+
+// autoDecoder tries every decoder that recognizes the payload until one succeeds
+type autoDecoder struct {
+	chain    []namedDecoder
+	disabled []string // The formats that aren't configured with the reason
+}
+
+// newAutoDecoder skips the formats that aren't configured for the connection
+// and moves the fallbacks to the end of the chain.
+func (k *KafkaConfig) newAutoDecoder() *autoDecoder {
+	decodersMu.Lock()
+	names := append([]string{}, decoderNames...)
+	factories := make([]DecoderFactory, 0, len(names))
+	for _, name := range names {
+		factories = append(factories, decoders[name])
+	}
+	decodersMu.Unlock()
+
+	auto := &autoDecoder{}
+	fallbacks := make([]namedDecoder, 0)
+
+	for i, factory := range factories {
+		// The hex dump is shown anyway when nothing matches
+		if names[i] == "HEX" {
+			continue
+		}
+
+		decoder, err := factory(k)
+		if err != nil {
+			auto.disabled = append(auto.disabled, fmt.Sprintf("%s (%v)", names[i], err))
+			continue
+		}
+
+		if isFallback(decoder) {
+			fallbacks = append(fallbacks, namedDecoder{name: names[i], Decoder: decoder})
+			continue
+		}
+
+		auto.chain = append(auto.chain, namedDecoder{name: names[i], Decoder: decoder})
+	}
+
+	auto.chain = append(auto.chain, fallbacks...)
+
+	return auto
+}
+
+// DisabledDecoders lists the formats skipped by an AUTO decoder with the reason, it is empty for the other decoders.
+func DisabledDecoders(decoder Decoder) []string {
+	auto, ok := decoder.(*autoDecoder)
+	if !ok {
+		return nil
+	}
+
+	return auto.disabled
+}
+
+func (a *autoDecoder) Sniff(value []byte) bool {
+	return true
+}
+
+func (a *autoDecoder) Decode(msg models.Message) (*models.Message, error) {
+	failures := make([]string, 0)
+
+	for _, decoder := range a.chain {
+		if !decoder.Sniff(msg.Value) {
+			continue
+		}
+
+		decoded, err := decoder.Decode(msg)
+		if err == nil {
+			return decoded, nil
+		}
+
+		failures = append(failures, decoder.name+": "+err.Error())
+	}
+
+	if len(failures) == 0 {
+		return nil, errors.New("the payload doesn't match any of the configured data formats")
+	}
+
+	return nil, fmt.Errorf("no data format could decode the payload (%s)", strings.Join(failures, "; "))
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+func TestAutoDecoderTriesFallbacksLast(t *testing.T) {
+	// A one byte int schema decodes any payload of a single byte below 0x80
+	schemaFile := filepath.Join(t.TempDir(), "int.avsc")
+	err := os.WriteFile(schemaFile, []byte(`"int"`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &KafkaConfig{AvroConfig: NewAvroConfig(&models.AvroConfig{SCHEMA_FILE: schemaFile})}
+
+	decoder, err := config.NewDecoder("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value []byte
+		want  string
+	}{
+		{value: []byte(`{"a":1}`), want: `{"a":1}`},
+		{value: []byte("a"), want: `{"text":"a"}`},
+		{value: []byte{0x02}, want: "1"},
+	}
+
+	for _, tt := range tests {
+		got, err := decoder.Decode(models.Message{Value: tt.value})
+		if err != nil {
+			t.Errorf("Decode(%q) error: %v", tt.value, err)
+			continue
+		}
+
+		if string(got.Value) != tt.want {
+			t.Errorf("Decode(%q) = %s, want %s", tt.value, got.Value, tt.want)
+		}
+	}
+}
+
+func TestAutoDecoderReportsDisabledFormats(t *testing.T) {
+	config := &KafkaConfig{}
+
+	decoder, err := config.NewDecoder(DecoderAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := strings.Join(DisabledDecoders(decoder), ", ")
+	if !strings.Contains(disabled, "AVRO (") || !strings.Contains(disabled, "PROTOBUF (") {
+		t.Errorf("DisabledDecoders = %q, want the formats needing a schema", disabled)
+	}
+
+	if strings.Contains(disabled, "JSON") || strings.Contains(disabled, "HEX") {
+		t.Errorf("DisabledDecoders = %q, want only the formats that failed", disabled)
+	}
+
+	decoder, err = config.NewDecoder("JSON")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DisabledDecoders(decoder); len(got) != 0 {
+		t.Errorf("DisabledDecoders(JSON) = %q, want none", got)
+	}
+}
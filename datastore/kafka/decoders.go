@@ -0,0 +1,307 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+func init() {
+	RegisterDecoder("JSON", func(k *KafkaConfig) (Decoder, error) { return jsonDecoder{}, nil })
+	RegisterDecoder("AVRO", newAvroDecoder)
+	RegisterDecoder("PROTOBUF", newProtobufDecoder)
+	RegisterDecoder("AVRO_LOCAL", newLocalAvroDecoder)
+	RegisterDecoder("AVRO_SUBJECT", newSubjectAvroDecoder)
+	RegisterDecoder("MSGPACK", func(k *KafkaConfig) (Decoder, error) { return msgpackDecoder{}, nil })
+	RegisterDecoder("CBOR", newCBORDecoder)
+	RegisterDecoder("TEXT", func(k *KafkaConfig) (Decoder, error) { return textDecoder{}, nil })
+	RegisterDecoder("HEX", func(k *KafkaConfig) (Decoder, error) { return hexDecoder{}, nil })
+}
+
+// withValue returns a copy of the message with the decoded JSON value.
+func withValue(msg models.Message, value interface{}) (*models.Message, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	finalMsg := msg
+	finalMsg.Value = data
+
+	return &finalMsg, nil
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Sniff(value []byte) bool {
+	value = bytes.TrimSpace(value)
+
+	return len(value) > 0 && (value[0] == '{' || value[0] == '[')
+}
+
+func (jsonDecoder) Decode(msg models.Message) (*models.Message, error) {
+	if !json.Valid(msg.Value) {
+		return nil, errors.New("payload is not valid JSON")
+	}
+
+	return &msg, nil
+}
+
+type avroDecoder struct {
+	*AvroConfig
+}
+
+func newAvroDecoder(k *KafkaConfig) (Decoder, error) {
+	if k.AvroConfig == nil || k.AvroConfig.Registry == nil {
+		return nil, errors.New("a schema registry URL is required")
+	}
+
+	return avroDecoder{k.AvroConfig}, nil
+}
+
+func (avroDecoder) Sniff(value []byte) bool {
+	return len(value) >= 5 && value[0] == 0
+}
+
+func (a avroDecoder) Decode(msg models.Message) (*models.Message, error) {
+	return a.ProcessAvroMessage(msg)
+}
+
+type localAvroDecoder struct {
+	*AvroConfig
+}
+
+func newLocalAvroDecoder(k *KafkaConfig) (Decoder, error) {
+	if k.AvroConfig == nil {
+		return nil, errors.New("a local Avro schema file is required")
+	}
+
+	err := k.AvroConfig.LoadSchemaFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return localAvroDecoder{k.AvroConfig}, nil
+}
+
+// Sniff can't tell raw Avro apart from other binary payloads
+func (localAvroDecoder) Sniff(value []byte) bool {
+	return len(value) > 0
+}
+
+func (localAvroDecoder) Fallback() bool {
+	return true
+}
+
+func (a localAvroDecoder) Decode(msg models.Message) (*models.Message, error) {
+	return a.ProcessLocalAvroMessage(msg)
+}
+
+type subjectAvroDecoder struct {
+	*AvroConfig
+}
+
+func newSubjectAvroDecoder(k *KafkaConfig) (Decoder, error) {
+	if k.AvroConfig == nil {
+		return nil, errors.New("a schema registry URL and subject are required")
+	}
+
+	err := k.AvroConfig.LoadSubject()
+	if err != nil {
+		return nil, err
+	}
+
+	return subjectAvroDecoder{k.AvroConfig}, nil
+}
+
+// Sniff can't tell raw Avro apart from other binary payloads
+func (subjectAvroDecoder) Sniff(value []byte) bool {
+	return len(value) > 0
+}
+
+func (subjectAvroDecoder) Fallback() bool {
+	return true
+}
+
+func (a subjectAvroDecoder) Decode(msg models.Message) (*models.Message, error) {
+	return a.ProcessSubjectAvroMessage(msg)
+}
+
+type protobufDecoder struct {
+	*ProtobufConfig
+}
+
+func newProtobufDecoder(k *KafkaConfig) (Decoder, error) {
+	err := k.ProtobufConfig.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return protobufDecoder{k.ProtobufConfig}, nil
+}
+
+func (p protobufDecoder) Sniff(value []byte) bool {
+	if p.local != nil {
+		return len(value) > 0
+	}
+
+	return len(value) >= 6 && value[0] == 0
+}
+
+// Fallback is true for a local descriptor, there is no magic byte to sniff then
+func (p protobufDecoder) Fallback() bool {
+	return p.local != nil
+}
+
+func (p protobufDecoder) Decode(msg models.Message) (*models.Message, error) {
+	return p.ProcessProtobufMessage(msg)
+}
+
+type msgpackDecoder struct{}
+
+// Sniff accepts the map and array markers, scalars are rarely sent on their own
+func (msgpackDecoder) Sniff(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	b := value[0]
+
+	return (b >= 0x80 && b <= 0x9f) || b == 0xdc || b == 0xdd || b == 0xde || b == 0xdf
+}
+
+func (msgpackDecoder) Decode(msg models.Message) (*models.Message, error) {
+	reader := bytes.NewReader(msg.Value)
+	decoder := msgpack.NewDecoder(reader)
+	decoder.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+
+	value, err := decoder.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	if reader.Len() > 0 {
+		return nil, errors.New("payload has trailing bytes after the MessagePack value")
+	}
+
+	return withValue(msg, jsonCompatible(value))
+}
+
+type cborDecoder struct {
+	mode cbor.DecMode
+}
+
+func newCBORDecoder(k *KafkaConfig) (Decoder, error) {
+	mode, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}{})}.DecMode()
+	if err != nil {
+		return nil, err
+	}
+
+	return cborDecoder{mode: mode}, nil
+}
+
+// Sniff accepts the array and map major types and the self-described CBOR tag
+func (cborDecoder) Sniff(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	if bytes.HasPrefix(value, []byte{0xd9, 0xd9, 0xf7}) {
+		return true
+	}
+
+	majorType := value[0] >> 5
+
+	return majorType == 4 || majorType == 5
+}
+
+func (c cborDecoder) Decode(msg models.Message) (*models.Message, error) {
+	var value interface{}
+
+	err := c.mode.Unmarshal(msg.Value, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	return withValue(msg, jsonCompatible(value))
+}
+
+type textDecoder struct{}
+
+func (textDecoder) Sniff(value []byte) bool {
+	if len(value) == 0 || !utf8.Valid(value) {
+		return false
+	}
+
+	for _, r := range string(value) {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t textDecoder) Decode(msg models.Message) (*models.Message, error) {
+	if !t.Sniff(msg.Value) {
+		return nil, errors.New("payload is not printable UTF-8 text")
+	}
+
+	return withValue(msg, map[string]interface{}{"text": string(msg.Value)})
+}
+
+type hexDecoder struct{}
+
+func (hexDecoder) Sniff(value []byte) bool {
+	return true
+}
+
+func (hexDecoder) Decode(msg models.Message) (*models.Message, error) {
+	return withValue(msg, map[string]interface{}{
+		"length": len(msg.Value),
+		"hex":    hex.EncodeToString(msg.Value),
+		"base64": base64.StdEncoding.EncodeToString(msg.Value),
+	})
+}
+
+// jsonCompatible converts the maps with non string keys that json.Marshal rejects.
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+
+		return v
+
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+
+		return converted
+
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+
+		return v
+	}
+
+	return value
+}
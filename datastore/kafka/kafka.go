@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
@@ -18,24 +17,57 @@ type KafkaConfig struct {
 	KAFKA_TOPIC             string   `json:"KAFKA_TOPIC"`
 	KAFKA_CONSUMER_GROUP_ID string   `json:"KAFKA_CONSUMER_GROUP_ID"`
 	KAFKA_CONSUMER_OFFSET   string   `json:"KAFKA_CONSUMER_OFFSET"`
+	KAFKA_PARTITIONS        string   `json:"KAFKA_PARTITIONS"` // Optional partition list eg: 0,3,7-9, only for a single topic
 	KAFKA_SASL_USER         string   `json:"KAFKA_SASL_USER"`
 	KAFKA_SASL_PASS         string   `json:"KAFKA_SASL_PASS"`
 	KAFKA_SASL_MECHANISM    string   `json:"KAFKA_SASL_MECHANISM"` // We have enabled the SASL authentication (User, Password)
+	DATA_FORMAT             string   `json:"DATA_FORMAT"`          // Name of a registered decoder, AUTO when empty
 	OFFSET                  int64    // This is the sarama offset value will be populated automatically
 	TOPICS                  []string // This will be populated automatically
 	*models.AzureConfig
 	*AvroConfig
+	*ProtobufConfig
+	*models.TLSConfig
+	*models.KerberosConfig
+
+	TOPIC_DATA_FORMATS map[string]string `json:"TOPIC_DATA_FORMATS"` // Overrides DATA_FORMAT per topic
+	COMPRESSION        string            `json:"COMPRESSION"`        // Payload compression applied by the producer, AUTO when empty
+	TOPIC_COMPRESSIONS map[string]string `json:"TOPIC_COMPRESSIONS"` // Overrides COMPRESSION per topic
+
+	// Populated automatically from KAFKA_CONSUMER_OFFSET and KAFKA_PARTITIONS
+	OFFSET_SPEC OffsetSpec
+	OFFSETS     map[string]map[int32]int64 // Resolved offset per topic and partition
+	OFFSET_LOGS map[string][]string        // Offsets that were clamped per topic, sent to the topic when consuming starts
+	PARTITIONS  []int32                    // Selected partitions, empty means all
 }
 
 type KafkaConsumer struct {
 	sarama.Consumer
 	sarama.ConsumerGroup
 	Configs *KafkaConfig
+
+	// WhenPaused calls pause if the connection is paused, PauseAll only applies to the partitions
+	// already consumed so the ones started later have to be paused one by one
+	WhenPaused func(pause func())
 }
 
 func (k *KafkaConfig) EstablishKafkaConn() (sarama.Client, error) {
 	config := sarama.NewConfig()
 
+	brokers, err := ParseBrokers(k.KAFKA_HOSTS)
+	if err != nil {
+		return nil, err
+	}
+
+	offsetSpec, err := ParseOffset(k.KAFKA_CONSUMER_OFFSET)
+	if err != nil {
+		return nil, err
+	}
+
+	k.OFFSET_SPEC = offsetSpec
+	k.OFFSET = offsetSpec.Initial()
+	config.Consumer.Offsets.Initial = k.OFFSET
+
 	config.Net.TLS.Enable = true
 	config.Consumer.Return.Errors = true
 	config.Net.SASL.Enable = true
@@ -44,6 +76,11 @@ func (k *KafkaConfig) EstablishKafkaConn() (sarama.Client, error) {
 	config.Consumer.Group.Session.Timeout = 30 * time.Second
 	config.Consumer.Group.Heartbeat.Interval = 10 * time.Second
 
+	// The Produce tab sends through a SyncProducer built from the same client
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Partitioner = newSelectablePartitioner
+
 	switch k.KAFKA_SASL_MECHANISM {
 	case "PLAIN":
 		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
@@ -51,18 +88,51 @@ func (k *KafkaConfig) EstablishKafkaConn() (sarama.Client, error) {
 		config.Net.SASL.Password = k.KAFKA_SASL_PASS
 
 	case "OUTHBEARER":
+		err := k.validateAzureConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		tokenProvider, err := NewTokenProvider(k)
+		if err != nil {
+			return nil, err
+		}
+
+		// Fetching a token now reports a wrong credential instead of a broker disconnect
+		_, err = tokenProvider.Token()
+		if err != nil {
+			return nil, err
+		}
+
 		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
-		config.Net.SASL.TokenProvider = NewTokenProvider(k)
+		config.Net.SASL.TokenProvider = tokenProvider
 		config.Net.SASL.Version = sarama.SASLHandshakeV0
 
-		if k == nil {
-			return nil, errors.New("azure Configs are not provided")
+	case "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if k.KAFKA_SASL_USER == "" || k.KAFKA_SASL_PASS == "" {
+			return nil, errors.New("SASL username and password are required for SCRAM")
+		}
+
+		config.Net.SASL.User = k.KAFKA_SASL_USER
+		config.Net.SASL.Password = k.KAFKA_SASL_PASS
+
+		if k.KAFKA_SASL_MECHANISM == "SCRAM-SHA-512" {
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA512} }
+		} else {
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA256} }
 		}
 
-		if k.AAD_APPLICATION_ID == "" || k.AAD_APPLICATION_SECRET == "" || k.AAD_TENANT_ID == "" || k.AAD_AUDIENCE == "" {
-			return nil, errors.New("missing azure configs")
+	case "GSSAPI":
+		gssapi, err := k.newGSSAPIConfig()
+		if err != nil {
+			return nil, err
 		}
 
+		config.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+		config.Net.SASL.GSSAPI = gssapi
+
 	case "PLAINTEXT":
 		config.Net.SASL.Enable = false
 		config.Net.TLS.Enable = false
@@ -71,12 +141,26 @@ func (k *KafkaConfig) EstablishKafkaConn() (sarama.Client, error) {
 		return nil, errors.New("unsupported SASL mechanism")
 	}
 
+	// TLS is chosen separately from the SASL mechanism when the TLS section is provided
+	if k.TLSConfig != nil {
+		config.Net.TLS.Enable = k.TLS_ENABLED
+
+		if k.TLS_ENABLED {
+			tlsConfig, err := k.newTLSConfig()
+			if err != nil {
+				return nil, err
+			}
+
+			config.Net.TLS.Config = tlsConfig
+		}
+	}
+
 	config.Consumer.Group.Member.UserData = []byte(k.KAFKA_CONSUMER_GROUP_ID)
 
 	config.ClientID = "sarama"
 	config.Version = sarama.V2_0_0_0
 
-	client, err := sarama.NewClient([]string{k.KAFKA_HOSTS}, config)
+	client, err := sarama.NewClient(brokers, config)
 	if err != nil {
 		return nil, err
 	}
@@ -108,8 +192,6 @@ func CreateConsumer(client sarama.Client) (sarama.Consumer, error) {
 }
 
 func (k *KafkaConfig) validateTopicAndOffset(client sarama.Client) error {
-	var offset int64
-
 	// Get a list of topics present in the cluster
 	activeTopics, err := client.Topics()
 	if err != nil {
@@ -134,46 +216,134 @@ func (k *KafkaConfig) validateTopicAndOffset(client sarama.Client) error {
 		}
 	}
 
-	offsetVal := strings.ToUpper(k.KAFKA_CONSUMER_OFFSET)
+	k.PARTITIONS, err = utils.ParsePartitions(k.KAFKA_PARTITIONS)
+	if err != nil {
+		return err
+	}
 
-	switch offsetVal {
-	case "OLDEST", "EARLIEST":
-		offset = sarama.OffsetOldest
-	case "LATEST":
-		offset = sarama.OffsetNewest
-	default:
-		return fmt.Errorf("error invalid offset: %v", k.KAFKA_CONSUMER_OFFSET)
+	if len(k.PARTITIONS) > 0 && len(consumeFromTopics) > 1 {
+		return errors.New("partitions can only be selected when consuming from a single topic")
 	}
 
-	k.OFFSET = offset
+	// Resolve the offset of every partition now so timestamps are looked up once
+	k.OFFSETS = make(map[string]map[int32]int64, 0)
+	k.OFFSET_LOGS = make(map[string][]string, 0)
+	for _, topic := range consumeFromTopics {
+		partitions, err := k.selectPartitions(client, topic)
+		if err != nil {
+			return err
+		}
+
+		k.OFFSETS[topic] = make(map[int32]int64, 0)
+		for _, partition := range partitions {
+			offset, log, err := k.OFFSET_SPEC.Resolve(client, topic, partition)
+			if err != nil {
+				return err
+			}
+
+			k.OFFSETS[topic][partition] = offset
+
+			if log != "" {
+				k.OFFSET_LOGS[topic] = append(k.OFFSET_LOGS[topic], log)
+			}
+		}
+	}
 
 	return nil
 }
 
-func (k *KafkaConsumer) ReadFromConsumer(channelMap map[string]chan models.Message) error {
+// selectPartitions returns the selected partitions after checking they exist, or all the partitions of the topic.
+func (k *KafkaConfig) selectPartitions(client sarama.Client, topic string) ([]int32, error) {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(k.PARTITIONS) == 0 {
+		return partitions, nil
+	}
+
+	available := make(map[int32]bool, 0)
+	for _, partition := range partitions {
+		available[partition] = true
+	}
+
+	for _, partition := range k.PARTITIONS {
+		if !available[partition] {
+			return nil, fmt.Errorf("partition %d does not exist in topic %s, it has %d partitions", partition, topic, len(partitions))
+		}
+	}
+
+	return k.PARTITIONS, nil
+}
+
+// PartitionOffset returns the offset to start consuming the partition from.
+func (k *KafkaConfig) PartitionOffset(topic string, partition int32) int64 {
+	offset, ok := k.OFFSETS[topic][partition]
+	if !ok {
+		return k.OFFSET
+	}
+
+	return offset
+}
+
+// sendOffsetLogs reports the offsets that were clamped, it returns false once the context is cancelled.
+func (k *KafkaConsumer) sendOffsetLogs(ctx context.Context, channelMap map[string]chan models.Message) bool {
+	for topic, logs := range k.Configs.OFFSET_LOGS {
+		if channelMap[topic] == nil {
+			continue
+		}
+
+		for _, log := range logs {
+			if !send(ctx, channelMap[topic], models.Message{Logs: log}) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ReadFromConsumer consumes the selected partitions until the context is cancelled.
+func (k *KafkaConsumer) ReadFromConsumer(ctx context.Context, channelMap map[string]chan models.Message) error {
 	topic := k.Configs.KAFKA_TOPIC
-	offset := k.Configs.OFFSET
 
-	partitions, err := k.Consumer.Partitions(topic)
-	if err != nil {
-		return fmt.Errorf("No partitions found in the cluster: " + err.Error())
+	if !k.sendOffsetLogs(ctx, channelMap) {
+		return nil
+	}
+
+	partitions := k.Configs.PARTITIONS
+	if len(partitions) == 0 {
+		var err error
+
+		partitions, err = k.Consumer.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("No partitions found in the cluster: " + err.Error())
+		}
 	}
 
 	var wg sync.WaitGroup
 	for j := range partitions {
 		wg.Add(1)
-		pc, err := k.Consumer.ConsumePartition(topic, partitions[j], offset)
+		pc, err := k.Consumer.ConsumePartition(topic, partitions[j], k.Configs.PartitionOffset(topic, partitions[j]))
 		if err != nil {
 			return fmt.Errorf("Couldn't consume partition due to error: " + err.Error())
 		}
 
+		if k.WhenPaused != nil {
+			k.WhenPaused(pc.Pause)
+		}
+
 		go func(j int) {
 			defer wg.Done()
+			defer pc.Close()
 
 			log := fmt.Sprintf("Started consuming from partition[%d]\n", partitions[j])
-			channelMap[k.Configs.TOPICS[0]] <- models.Message{Logs: log}
+			if !send(ctx, channelMap[k.Configs.TOPICS[0]], models.Message{Logs: log}) {
+				return
+			}
 
-			ConsumeMessages(pc, channelMap)
+			ConsumeMessages(ctx, pc, channelMap)
 
 		}(j)
 	}
@@ -183,30 +353,71 @@ func (k *KafkaConsumer) ReadFromConsumer(channelMap map[string]chan models.Messa
 	return nil
 }
 
-func (k *KafkaConsumer) ReadFromConsumerGroup(channelMap map[string]chan models.Message) error {
+// ReadFromConsumerGroup rejoins the group after every rebalance until the context is cancelled.
+func (k *KafkaConsumer) ReadFromConsumerGroup(ctx context.Context, channelMap map[string]chan models.Message) error {
 	topics := k.Configs.TOPICS
 
+	if !k.sendOffsetLogs(ctx, channelMap) {
+		return nil
+	}
+
+	// The handler outlives the sessions so the offsets are only applied once and not on every rebalance
+	handler := &ConsumerHandler{ChannelMap: channelMap, Configs: k.Configs, WhenPaused: k.WhenPaused, ctx: ctx, group: k.ConsumerGroup}
+
 	for {
-		err := k.ConsumerGroup.Consume(context.Background(), topics, &ConsumerHandler{channelMap})
+		err := k.ConsumerGroup.Consume(ctx, topics, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+
 		if err != nil {
 			return err
 		}
 	}
 }
 
-func ConsumeMessages(pc sarama.PartitionConsumer, channelMap map[string]chan models.Message) {
-	for msg := range pc.Messages() {
+func ConsumeMessages(ctx context.Context, pc sarama.PartitionConsumer, channelMap map[string]chan models.Message) {
+	for {
+		var msg *sarama.ConsumerMessage
+
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-pc.Messages():
+			if !ok {
+				return
+			}
+
+			msg = m
+		}
+
 		headers := convertSaramaHeaderToMap(msg.Headers)
 		data := models.Message{
-			Headers:   headers,
-			Offset:    msg.Offset,
-			Topic:     msg.Topic,
-			Partition: msg.Partition,
-			Value:     msg.Value,
-			Logs:      fmt.Sprintf("Consumed from topic %v, Partition %v with Offset %v\n", msg.Topic, msg.Partition, msg.Offset),
+			Headers:        headers,
+			Key:            msg.Key,
+			Offset:         msg.Offset,
+			Topic:          msg.Topic,
+			Partition:      msg.Partition,
+			Value:          msg.Value,
+			Timestamp:      msg.Timestamp,
+			BlockTimestamp: msg.BlockTimestamp,
+			Logs:           fmt.Sprintf("Consumed from topic %v, Partition %v with Offset %v\n", msg.Topic, msg.Partition, msg.Offset),
+		}
+
+		if !send(ctx, channelMap[msg.Topic], data) {
+			return
 		}
+	}
+}
 
-		channelMap[msg.Topic] <- data
+// send delivers the message unless the context is cancelled first, the channels
+// are closed on disconnect so nothing may be sent after that.
+func send(ctx context.Context, channel chan models.Message, msg models.Message) bool {
+	select {
+	case channel <- msg:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
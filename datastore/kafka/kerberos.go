@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	KRB5AuthKeytab = "KEYTAB"
+	KRB5AuthCCache = "CCACHE"
+)
+
+// newGSSAPIConfig validates the kerberos section and converts it to the sarama configuration.
+func (k *KafkaConfig) newGSSAPIConfig() (sarama.GSSAPIConfig, error) {
+	var gssapi sarama.GSSAPIConfig
+
+	if k.KerberosConfig == nil {
+		return gssapi, errors.New("kerberos configs are not provided")
+	}
+
+	if k.KRB5_CONFIG_PATH == "" || k.KRB5_PRINCIPAL == "" {
+		return gssapi, errors.New("krb5.conf path and principal are required for GSSAPI")
+	}
+
+	_, err := os.Stat(k.KRB5_CONFIG_PATH)
+	if err != nil {
+		return gssapi, fmt.Errorf("error reading krb5.conf: %v", err)
+	}
+
+	username, realm, _ := strings.Cut(k.KRB5_PRINCIPAL, "@")
+	if k.KRB5_REALM != "" {
+		realm = k.KRB5_REALM
+	}
+
+	if realm == "" {
+		return gssapi, errors.New("kerberos realm is required, either in the principal or as a separate field")
+	}
+
+	gssapi = sarama.GSSAPIConfig{
+		KerberosConfigPath: k.KRB5_CONFIG_PATH,
+		ServiceName:        k.KRB5_SERVICE_NAME,
+		Username:           username,
+		Realm:              realm,
+		DisablePAFXFAST:    k.KRB5_DISABLE_PA_FX_FAST,
+	}
+
+	if gssapi.ServiceName == "" {
+		gssapi.ServiceName = "kafka"
+	}
+
+	switch strings.ToUpper(k.KRB5_AUTH_TYPE) {
+	case KRB5AuthKeytab:
+		if k.KRB5_KEYTAB_PATH == "" {
+			return gssapi, errors.New("keytab path is required for keytab authentication")
+		}
+
+		gssapi.AuthType = sarama.KRB5_KEYTAB_AUTH
+		gssapi.KeyTabPath = k.KRB5_KEYTAB_PATH
+
+	case KRB5AuthCCache:
+		if k.KRB5_CCACHE_PATH == "" {
+			return gssapi, errors.New("credential cache path is required for ccache authentication")
+		}
+
+		gssapi.AuthType = sarama.KRB5_CCACHE_AUTH
+		gssapi.CCachePath = k.KRB5_CCACHE_PATH
+
+	default:
+		return gssapi, fmt.Errorf("unsupported kerberos auth type %q, expected KEYTAB or CCACHE", k.KRB5_AUTH_TYPE)
+	}
+
+	return gssapi, nil
+}
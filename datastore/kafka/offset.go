@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+type OffsetKind int
+
+const (
+	OffsetOldest OffsetKind = iota
+	OffsetLatest
+	OffsetAbsolute
+	OffsetLatestMinus
+	OffsetTimestamp
+)
+
+// OffsetSpec is the parsed KAFKA_CONSUMER_OFFSET value
+type OffsetSpec struct {
+	Kind  OffsetKind
+	Value int64 // Absolute offset or the N of LATEST-N
+	Time  time.Time
+}
+
+var offsetTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseOffset accepts OLDEST/EARLIEST, LATEST, an absolute offset, LATEST-N
+// or an ISO-8601 timestamp. Timestamps without a zone are in local time.
+func ParseOffset(s string) (OffsetSpec, error) {
+	val := strings.ToUpper(strings.TrimSpace(s))
+
+	switch val {
+	case "OLDEST", "EARLIEST":
+		return OffsetSpec{Kind: OffsetOldest}, nil
+	case "LATEST":
+		return OffsetSpec{Kind: OffsetLatest}, nil
+	}
+
+	if rest := strings.TrimPrefix(val, "LATEST"); rest != val {
+		rest = strings.TrimSpace(rest)
+
+		n, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(rest, "-")), 10, 64)
+		if !strings.HasPrefix(rest, "-") || err != nil || n < 0 {
+			return OffsetSpec{}, fmt.Errorf("error invalid offset: %v, expected eg: LATEST-100", s)
+		}
+
+		return OffsetSpec{Kind: OffsetLatestMinus, Value: n}, nil
+	}
+
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		if n < 0 {
+			return OffsetSpec{}, fmt.Errorf("error invalid offset: %v, offsets can't be negative", s)
+		}
+
+		return OffsetSpec{Kind: OffsetAbsolute, Value: n}, nil
+	}
+
+	for _, layout := range offsetTimeLayouts {
+		t, err := time.ParseInLocation(layout, strings.TrimSpace(s), time.Local)
+		if err == nil {
+			return OffsetSpec{Kind: OffsetTimestamp, Time: t}, nil
+		}
+	}
+
+	return OffsetSpec{}, fmt.Errorf("error invalid offset: %v", s)
+}
+
+// Initial is the sarama offset used when no offset was resolved for a partition.
+func (o OffsetSpec) Initial() int64 {
+	if o.Kind == OffsetOldest {
+		return sarama.OffsetOldest
+	}
+
+	return sarama.OffsetNewest
+}
+
+// Resolve returns the offset to start consuming the partition from. An absolute offset outside
+// of the partition is clamped to its oldest or newest offset and the returned log says so.
+func (o OffsetSpec) Resolve(client sarama.Client, topic string, partition int32) (int64, string, error) {
+	switch o.Kind {
+	case OffsetOldest:
+		return sarama.OffsetOldest, "", nil
+
+	case OffsetLatest:
+		return sarama.OffsetNewest, "", nil
+
+	case OffsetTimestamp:
+		offset, err := client.GetOffset(topic, partition, o.Time.UnixMilli())
+		if err != nil {
+			return 0, "", err
+		}
+
+		// No message was produced after the timestamp
+		if offset == -1 {
+			return sarama.OffsetNewest, "", nil
+		}
+
+		return offset, "", nil
+	}
+
+	oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, "", err
+	}
+
+	newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if o.Kind == OffsetLatestMinus {
+		offset := newest - o.Value
+		if offset < oldest {
+			offset = oldest
+		}
+
+		return offset, "", nil
+	}
+
+	// The partitions of a topic rarely hold the same range of offsets
+	offset := o.Value
+	if offset < oldest {
+		offset = oldest
+	} else if offset > newest {
+		offset = newest
+	}
+
+	if offset != o.Value {
+		log := fmt.Sprintf("Offset %d is out of range [%d, %d] for partition %d of topic %s, starting from %d\n", o.Value, oldest, newest, partition, topic, offset)
+		return offset, log, nil
+	}
+
+	return offset, "", nil
+}
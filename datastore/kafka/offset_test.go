@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OffsetSpec
+		wantErr bool
+	}{
+		{input: "OLDEST", want: OffsetSpec{Kind: OffsetOldest}},
+		{input: "earliest", want: OffsetSpec{Kind: OffsetOldest}},
+		{input: " latest ", want: OffsetSpec{Kind: OffsetLatest}},
+		{input: "LATEST-100", want: OffsetSpec{Kind: OffsetLatestMinus, Value: 100}},
+		{input: "latest - 5", want: OffsetSpec{Kind: OffsetLatestMinus, Value: 5}},
+		{input: "LATEST+5", wantErr: true},
+		{input: "LATEST-x", wantErr: true},
+		{input: "42", want: OffsetSpec{Kind: OffsetAbsolute, Value: 42}},
+		{input: "-1", wantErr: true},
+		{input: "2024-05-01T10:00:00Z", want: OffsetSpec{Kind: OffsetTimestamp, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}},
+		{input: "2024-05-01 10:00:00", want: OffsetSpec{Kind: OffsetTimestamp, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}},
+		{input: "2024-05-01", want: OffsetSpec{Kind: OffsetTimestamp, Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)}},
+		{input: "yesterday", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOffset(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOffset(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		if got.Kind != tt.want.Kind || got.Value != tt.want.Value || !got.Time.Equal(tt.want.Time) {
+			t.Errorf("ParseOffset(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// rangeClient reports a fixed [oldest, newest] range of offsets per partition.
+type rangeClient struct {
+	sarama.Client
+	ranges map[int32][2]int64
+}
+
+func (c rangeClient) GetOffset(topic string, partition int32, time int64) (int64, error) {
+	if time == sarama.OffsetOldest {
+		return c.ranges[partition][0], nil
+	}
+
+	return c.ranges[partition][1], nil
+}
+
+func TestResolveClampsToPartitionRange(t *testing.T) {
+	client := rangeClient{ranges: map[int32][2]int64{0: {0, 100}, 1: {500, 600}, 2: {50, 60}}}
+	spec := OffsetSpec{Kind: OffsetAbsolute, Value: 550}
+
+	tests := []struct {
+		partition int32
+		want      int64
+		clamped   bool
+	}{
+		{partition: 0, want: 100, clamped: true},
+		{partition: 1, want: 550},
+		{partition: 2, want: 60, clamped: true},
+	}
+
+	for _, tt := range tests {
+		got, log, err := spec.Resolve(client, "orders", tt.partition)
+		if err != nil {
+			t.Fatalf("Resolve(partition %d) error = %v", tt.partition, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("Resolve(partition %d) = %d, want %d", tt.partition, got, tt.want)
+		}
+
+		if (log != "") != tt.clamped {
+			t.Errorf("Resolve(partition %d) log = %q, clamped %v", tt.partition, log, tt.clamped)
+		}
+	}
+
+	got, log, err := OffsetSpec{Kind: OffsetAbsolute, Value: 10}.Resolve(client, "orders", 1)
+	if err != nil || got != 500 || !strings.Contains(log, "starting from 500") {
+		t.Errorf("Resolve(10, partition 1) = %d, %q, %v, want 500 and a log", got, log, err)
+	}
+}
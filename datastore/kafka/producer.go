@@ -0,0 +1,233 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	PartitionerHash       = "HASH"    // FNV-1a of the key like sarama, random for a null key
+	PartitionerMurmur2    = "MURMUR2" // murmur2 of the key like the Java client, random for a null key
+	PartitionerRandom     = "RANDOM"
+	PartitionerRoundRobin = "ROUND_ROBIN"
+	PartitionerManual     = "MANUAL" // The partition of the request
+)
+
+var Partitioners = []string{PartitionerHash, PartitionerMurmur2, PartitionerRandom, PartitionerRoundRobin, PartitionerManual}
+
+const (
+	EncodingJSON = "JSON"
+	EncodingAvro = "AVRO" // Confluent wire format with a schema from the registry
+)
+
+var Encodings = []string{EncodingJSON, EncodingAvro}
+
+// ProduceRequest is a message to publish, the body is sent as is or encoded as Avro.
+type ProduceRequest struct {
+	Topic       string
+	Key         []byte // Nil for a null key
+	Headers     map[string]string
+	Body        []byte // JSON, an empty body produces a tombstone
+	Partitioner string
+	Partition   int32 // Only used by the MANUAL partitioner
+	Encoding    string
+	AvroSubject string // <topic>-value when empty
+	AvroVersion string // latest when empty
+}
+
+func CreateProducer(client sarama.Client) (sarama.SyncProducer, error) {
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return producer, nil
+}
+
+// NewProducerMessage validates the request and encodes its body.
+func (k *KafkaConfig) NewProducerMessage(req ProduceRequest) (*sarama.ProducerMessage, error) {
+	if req.Topic == "" {
+		return nil, errors.New("a topic is required")
+	}
+
+	partitioner := req.Partitioner
+	if partitioner == "" {
+		partitioner = PartitionerHash
+	}
+
+	known := false
+	for _, name := range Partitioners {
+		if name == partitioner {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown partitioner %s, expected one of %s", partitioner, strings.Join(Partitioners, ", "))
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:     req.Topic,
+		Partition: req.Partition,
+		Metadata:  partitioner,
+	}
+
+	if req.Key != nil {
+		msg.Key = sarama.ByteEncoder(req.Key)
+	}
+
+	keys := make([]string, 0, len(req.Headers))
+	for key := range req.Headers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(req.Headers[key])})
+	}
+
+	if len(strings.TrimSpace(string(req.Body))) == 0 {
+		return msg, nil
+	}
+
+	if !json.Valid(req.Body) {
+		return nil, errors.New("the body is not valid JSON")
+	}
+
+	switch req.Encoding {
+	case "", EncodingJSON:
+		msg.Value = sarama.ByteEncoder(req.Body)
+
+	case EncodingAvro:
+		subject := req.AvroSubject
+		if subject == "" {
+			subject = req.Topic + "-value"
+		}
+
+		value, err := k.AvroConfig.EncodeAvroMessage(subject, req.AvroVersion, req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Value = sarama.ByteEncoder(value)
+
+	default:
+		return nil, fmt.Errorf("unknown encoding %s, expected one of %s", req.Encoding, strings.Join(Encodings, ", "))
+	}
+
+	return msg, nil
+}
+
+// selectablePartitioner applies the partitioner named in the metadata of every message,
+// the client is shared by every message of the Produce tab so its partitioner can't be fixed.
+type selectablePartitioner struct {
+	partitioners map[string]sarama.Partitioner
+}
+
+func newSelectablePartitioner(topic string) sarama.Partitioner {
+	return &selectablePartitioner{
+		partitioners: map[string]sarama.Partitioner{
+			PartitionerHash:       sarama.NewHashPartitioner(topic),
+			PartitionerMurmur2:    &murmur2Partitioner{random: sarama.NewRandomPartitioner(topic)},
+			PartitionerRandom:     sarama.NewRandomPartitioner(topic),
+			PartitionerRoundRobin: sarama.NewRoundRobinPartitioner(topic),
+			PartitionerManual:     sarama.NewManualPartitioner(topic),
+		},
+	}
+}
+
+func (p *selectablePartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	name, _ := msg.Metadata.(string)
+
+	partitioner, ok := p.partitioners[name]
+	if !ok {
+		partitioner = p.partitioners[PartitionerHash]
+	}
+
+	partition, err := partitioner.Partition(msg, numPartitions)
+	if err != nil {
+		return -1, err
+	}
+
+	if partition < 0 || partition >= numPartitions {
+		return -1, fmt.Errorf("partition %d does not exist in topic %s, it has %d partitions", partition, msg.Topic, numPartitions)
+	}
+
+	return partition, nil
+}
+
+// RequiresConsistency is true so keyed messages never move to another partition while a broker is down.
+func (p *selectablePartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// murmur2Partitioner places keyed messages on the same partition as the Java client's default partitioner.
+type murmur2Partitioner struct {
+	random sarama.Partitioner
+}
+
+func (p *murmur2Partitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if msg.Key == nil {
+		return p.random.Partition(msg, numPartitions)
+	}
+
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+
+	return (murmur2(key) & 0x7fffffff) % numPartitions, nil
+}
+
+func (p *murmur2Partitioner) RequiresConsistency() bool {
+	return true
+}
+
+// murmur2 is the hash of org.apache.kafka.common.utils.Utils.murmur2.
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+
+	return int32(h)
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// The expected hashes are the ones of the Java client's UtilsTest.testMurmur2
+func TestMurmur2(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int32
+	}{
+		{key: "21", want: -973932308},
+		{key: "foobar", want: -790332482},
+		{key: "a-little-bit-long-string", want: -985981536},
+		{key: "a-little-bit-longer-string", want: -1486304829},
+		{key: "lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", want: -58897971},
+		{key: "abc", want: 479470107},
+	}
+
+	for _, tt := range tests {
+		if got := murmur2([]byte(tt.key)); got != tt.want {
+			t.Errorf("murmur2(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMurmur2Partitioner(t *testing.T) {
+	partitioner := newSelectablePartitioner("orders")
+
+	msg := &sarama.ProducerMessage{Topic: "orders", Key: sarama.StringEncoder("foobar"), Metadata: PartitionerMurmur2}
+
+	partition, err := partitioner.Partition(msg, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (-790332482 & 0x7fffffff) % 12
+	if partition != 6 {
+		t.Errorf("partition = %d, want 6", partition)
+	}
+}
@@ -0,0 +1,336 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/krogertechnology/data-tracker/models"
+
+	// Registers the well known types, the registry doesn't return them as references
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type ProtobufConfig struct {
+	*models.ProtobufConfig
+	Registry *SchemaRegistry
+
+	local *protoSchema // Loaded from DESCRIPTOR_SET_PATH
+
+	mu      sync.Mutex
+	schemas map[int]*protoSchema // Registry schemas by ID
+}
+
+// protoSchema holds a file with its dependencies so the nested and Any messages can be resolved
+type protoSchema struct {
+	file    protoreflect.FileDescriptor
+	message protoreflect.MessageDescriptor // Only for the local descriptor set
+	types   *dynamicpb.Types
+}
+
+func NewProtobufConfig(config *models.ProtobufConfig, registry *SchemaRegistry) *ProtobufConfig {
+	if config == nil && registry == nil {
+		return nil
+	}
+
+	if config == nil {
+		config = &models.ProtobufConfig{}
+	}
+
+	return &ProtobufConfig{
+		ProtobufConfig: config,
+		Registry:       registry,
+		schemas:        make(map[int]*protoSchema, 0),
+	}
+}
+
+// Load reads the local descriptor set, it is called when connecting so a wrong file is reported right away.
+func (p *ProtobufConfig) Load() error {
+	if p == nil || (p.Registry == nil && p.DESCRIPTOR_SET_PATH == "") {
+		return errors.New("protobuf needs a schema registry URL or a descriptor set")
+	}
+
+	if p.DESCRIPTOR_SET_PATH == "" {
+		return nil
+	}
+
+	if p.MESSAGE_TYPE == "" {
+		return errors.New("the protobuf message type is required with a descriptor set")
+	}
+
+	data, err := os.ReadFile(p.DESCRIPTOR_SET_PATH)
+	if err != nil {
+		return fmt.Errorf("error reading descriptor set: %v", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(data, set)
+	if err != nil {
+		return fmt.Errorf("error parsing descriptor set: %v", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("error loading descriptor set, was it built with --include_imports? %v", err)
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(p.MESSAGE_TYPE))
+	if err != nil {
+		return fmt.Errorf("message type %s not found in the descriptor set", p.MESSAGE_TYPE)
+	}
+
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return fmt.Errorf("%s is not a message type", p.MESSAGE_TYPE)
+	}
+
+	p.local = &protoSchema{file: message.ParentFile(), message: message, types: dynamicpb.NewTypes(files)}
+
+	return nil
+}
+
+// ProcessProtobufMessage decodes a Confluent framed or a plain Protobuf payload to JSON.
+func (p *ProtobufConfig) ProcessProtobufMessage(msg models.Message) (*models.Message, error) {
+	if p == nil {
+		return nil, errors.New("no protobuf schema found for the data")
+	}
+
+	var (
+		message protoreflect.MessageDescriptor
+		types   *dynamicpb.Types
+		payload = msg.Value
+	)
+
+	// Field number 0 is invalid so a plain Protobuf payload never starts with 0x00
+	framed := len(payload) > 0 && payload[0] == 0
+
+	switch {
+	case framed && p.Registry != nil:
+		if len(payload) < 6 {
+			return nil, fmt.Errorf("payload of %d bytes is too short for the Confluent Protobuf wire format", len(payload))
+		}
+
+		schemaID := int(binary.BigEndian.Uint32(payload[1:5]))
+
+		indexes, rest, err := readMessageIndexes(payload[5:])
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err := p.registrySchema(schemaID)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err = messageByIndexes(schema.file, indexes)
+		if err != nil {
+			return nil, err
+		}
+
+		types = schema.types
+		payload = rest
+
+	case p.local != nil:
+		if framed {
+			if len(payload) < 6 {
+				return nil, fmt.Errorf("payload of %d bytes is too short for the Confluent Protobuf wire format", len(payload))
+			}
+
+			_, rest, err := readMessageIndexes(payload[5:])
+			if err != nil {
+				return nil, err
+			}
+
+			payload = rest
+		}
+
+		message = p.local.message
+		types = p.local.types
+
+	case framed:
+		return nil, errors.New("a schema registry URL is required to decode Confluent framed Protobuf")
+
+	default:
+		return nil, errors.New("a descriptor set and message type are required to decode plain Protobuf")
+	}
+
+	decoded := dynamicpb.NewMessage(message)
+
+	err := proto.UnmarshalOptions{Resolver: types}.Unmarshal(payload, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Protobuf data as %s: %v", message.FullName(), err)
+	}
+
+	value, err := protojson.MarshalOptions{Resolver: types}.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	finalMsg := msg
+	finalMsg.Value = value
+
+	return &finalMsg, nil
+}
+
+// readMessageIndexes reads the zigzag varint count and indexes of the message in the
+// schema file. The common case of the first message is written as a single 0.
+func readMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, nil, errors.New("invalid message indexes in the Confluent Protobuf wire format")
+	}
+
+	data = data[n:]
+
+	// Every index takes at least one byte so a larger count can't be valid
+	if count < 0 || count > int64(len(data)) {
+		return nil, nil, errors.New("invalid message indexes in the Confluent Protobuf wire format")
+	}
+
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+
+	indexes := make([]int, 0, count)
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(data)
+		if n <= 0 || index < 0 {
+			return nil, nil, errors.New("invalid message indexes in the Confluent Protobuf wire format")
+		}
+
+		indexes = append(indexes, int(index))
+		data = data[n:]
+	}
+
+	return indexes, data, nil
+}
+
+// messageByIndexes walks the top level messages of the file and then the nested ones.
+func messageByIndexes(file protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	messages := file.Messages()
+
+	var message protoreflect.MessageDescriptor
+	for _, index := range indexes {
+		if index >= messages.Len() {
+			return nil, fmt.Errorf("message index %v not found in %s", indexes, file.Path())
+		}
+
+		message = messages.Get(index)
+		messages = message.Messages()
+	}
+
+	return message, nil
+}
+
+func (p *ProtobufConfig) registrySchema(id int) (*protoSchema, error) {
+	p.mu.Lock()
+	schema, ok := p.schemas[id]
+	p.mu.Unlock()
+
+	if ok {
+		return schema, nil
+	}
+
+	registered, err := p.Registry.SerializedSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if registered.SchemaType != "PROTOBUF" {
+		return nil, fmt.Errorf("schema %d is not a PROTOBUF schema", id)
+	}
+
+	// Every schema gets its own files since two versions of a schema share the same file path
+	files := &protoregistry.Files{}
+
+	file, err := p.addFile(files, registered, strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+
+	schema = &protoSchema{file: file, types: dynamicpb.NewTypes(files)}
+
+	p.mu.Lock()
+	p.schemas[id] = schema
+	p.mu.Unlock()
+
+	return schema, nil
+}
+
+// addFile registers the references of the schema and then the schema itself.
+func (p *ProtobufConfig) addFile(files *protoregistry.Files, registered *RegisteredSchema, name string) (protoreflect.FileDescriptor, error) {
+	for _, ref := range registered.References {
+		_, err := files.FindFileByPath(ref.Name)
+		if err == nil {
+			continue
+		}
+
+		dependency, err := p.Registry.SubjectVersion(ref.Subject, strconv.Itoa(ref.Version), true)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.addFile(files, dependency, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(registered.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding serialized schema %s: %v", name, err)
+	}
+
+	fileProto := &descriptorpb.FileDescriptorProto{}
+	err = proto.Unmarshal(data, fileProto)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing serialized schema %s: %v", name, err)
+	}
+
+	for _, dependency := range fileProto.GetDependency() {
+		_, err := files.FindFileByPath(dependency)
+		if err == nil {
+			continue
+		}
+
+		wellKnown, err := protoregistry.GlobalFiles.FindFileByPath(dependency)
+		if err != nil {
+			return nil, fmt.Errorf("import %s of schema %s is not a registered reference", dependency, name)
+		}
+
+		err = files.RegisterFile(wellKnown)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := protodesc.NewFile(fileProto, files)
+	if err != nil {
+		return nil, fmt.Errorf("error loading schema %s: %v", name, err)
+	}
+
+	err = files.RegisterFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
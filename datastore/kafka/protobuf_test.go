@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// varints encodes the values the way the Confluent Protobuf wire format does.
+func varints(values ...int64) []byte {
+	var data []byte
+	for _, v := range values {
+		data = binary.AppendVarint(data, v)
+	}
+
+	return data
+}
+
+func TestReadMessageIndexes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		want     []int
+		wantRest []byte
+		wantErr  bool
+	}{
+		{name: "first message", input: append(varints(0), 0x0a), want: []int{0}, wantRest: []byte{0x0a}},
+		{name: "nested message", input: append(varints(2, 1, 3), 0x0a), want: []int{1, 3}, wantRest: []byte{0x0a}},
+		{name: "huge count", input: varints(1<<60, 1), wantErr: true},
+		{name: "negative count", input: varints(-1, 1), wantErr: true},
+		{name: "count past the data", input: varints(3, 1), wantErr: true},
+		{name: "negative index", input: varints(1, -2), wantErr: true},
+		{name: "empty", input: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, rest, err := readMessageIndexes(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: readMessageIndexes error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && (!reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(rest, tt.wantRest)) {
+			t.Errorf("%s: readMessageIndexes = %v, %v, want %v, %v", tt.name, got, rest, tt.want, tt.wantRest)
+		}
+	}
+}
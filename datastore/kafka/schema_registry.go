@@ -0,0 +1,367 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/linkedin/goavro"
+)
+
+const (
+	defaultRegistryTimeout = 10 * time.Second
+	defaultRegistryRetries = 3
+
+	// A failed lookup is not retried for every message of the same schema
+	failedLookupTTL = 30 * time.Second
+)
+
+// RegisteredSchema is a schema as returned by the registry
+type RegisteredSchema struct {
+	ID         int               `json:"id,omitempty"`
+	Subject    string            `json:"subject,omitempty"`
+	Version    int               `json:"version,omitempty"`
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType,omitempty"` // Empty means AVRO
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// SchemaRegistry fetches schemas by ID from a Confluent compatible registry. Schemas are immutable
+// so they are cached in memory and on disk, and the codecs are only built once per schema ID.
+type SchemaRegistry struct {
+	url      string
+	username string
+	password string
+	token    string
+	retries  int
+	cacheDir string
+	client   *http.Client
+
+	mu         sync.Mutex
+	schemas    map[string]*RegisteredSchema // Keyed by cacheKey
+	codecs     map[int]*goavro.Codec
+	failures   map[string]failedLookup // Keyed by cacheKey
+	failureTTL time.Duration
+}
+
+type failedLookup struct {
+	err   error
+	until time.Time
+}
+
+func NewSchemaRegistry(config *models.AvroConfig) *SchemaRegistry {
+	timeout := defaultRegistryTimeout
+	if config.SCHEMA_REGISTRY_TIMEOUT > 0 {
+		timeout = time.Duration(config.SCHEMA_REGISTRY_TIMEOUT) * time.Second
+	}
+
+	retries := defaultRegistryRetries
+	if config.SCHEMA_REGISTRY_RETRIES > 0 {
+		retries = config.SCHEMA_REGISTRY_RETRIES
+	}
+
+	registryURL := strings.TrimRight(config.SCHEMA_URL, "/")
+
+	return &SchemaRegistry{
+		url:        registryURL,
+		username:   config.SCHEMA_REGISTRY_USER,
+		password:   config.SCHEMA_REGISTRY_PASS,
+		token:      config.SCHEMA_REGISTRY_TOKEN,
+		retries:    retries,
+		cacheDir:   schemaCacheDir(config.SCHEMA_CACHE_DIR, registryURL),
+		client:     &http.Client{Timeout: timeout},
+		schemas:    make(map[string]*RegisteredSchema, 0),
+		codecs:     make(map[int]*goavro.Codec, 0),
+		failures:   make(map[string]failedLookup, 0),
+		failureTTL: failedLookupTTL,
+	}
+}
+
+// schemaCacheDir returns a directory per registry since the IDs are only unique within a registry.
+func schemaCacheDir(dir, registryURL string) string {
+	if dir == "-" {
+		return ""
+	}
+
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(userCacheDir, "data-tracker", "schemas")
+	}
+
+	sum := sha256.Sum256([]byte(registryURL))
+
+	return filepath.Join(dir, hex.EncodeToString(sum[:8]))
+}
+
+// Schema returns the schema registered with the ID.
+func (r *SchemaRegistry) Schema(id int) (*RegisteredSchema, error) {
+	schema, err := r.fetch(strconv.Itoa(id), fmt.Sprintf("/schemas/ids/%d", id), true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching schema %d: %v", id, err)
+	}
+
+	schema.ID = id
+
+	return schema, nil
+}
+
+// SerializedSchema returns the schema registered with the ID, Protobuf schemas
+// are returned as a base64 encoded FileDescriptorProto instead of the .proto source.
+func (r *SchemaRegistry) SerializedSchema(id int) (*RegisteredSchema, error) {
+	schema, err := r.fetch(strconv.Itoa(id)+"-serialized", fmt.Sprintf("/schemas/ids/%d?format=serialized", id), true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching schema %d: %v", id, err)
+	}
+
+	schema.ID = id
+
+	return schema, nil
+}
+
+// SubjectVersion returns a version of the subject, version is a number or latest.
+// Only numbered versions are cached on disk since latest changes when a new version is registered.
+func (r *SchemaRegistry) SubjectVersion(subject, version string, serialized bool) (*RegisteredSchema, error) {
+	path := fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
+	key := "subject-" + hex.EncodeToString([]byte(subject)) + "-" + version
+
+	if serialized {
+		path += "?format=serialized"
+		key += "-serialized"
+	}
+
+	_, err := strconv.Atoi(version)
+	numbered := err == nil
+
+	schema, err := r.fetch(key, path, numbered)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching version %s of subject %s: %v", version, subject, err)
+	}
+
+	return schema, nil
+}
+
+// fetch returns the cached schema or gets it from the registry, immutable schemas are also cached on disk.
+// A failed lookup returns the same error until failureTTL has passed.
+func (r *SchemaRegistry) fetch(key, path string, immutable bool) (*RegisteredSchema, error) {
+	r.mu.Lock()
+	schema, ok := r.schemas[key]
+	failure, failed := r.failures[key]
+	r.mu.Unlock()
+
+	if ok {
+		return schema, nil
+	}
+
+	if failed && time.Now().Before(failure.until) {
+		return nil, failure.err
+	}
+
+	var err error
+	if immutable {
+		schema, err = r.readCachedSchema(key)
+	}
+
+	if !immutable || err != nil {
+		schema = &RegisteredSchema{}
+
+		err = r.get(path, schema)
+		if err != nil {
+			r.mu.Lock()
+			r.failures[key] = failedLookup{err: err, until: time.Now().Add(r.failureTTL)}
+			r.mu.Unlock()
+
+			return nil, err
+		}
+
+		if immutable {
+			r.writeCachedSchema(key, schema)
+		}
+	}
+
+	r.mu.Lock()
+	r.schemas[key] = schema
+	delete(r.failures, key)
+	r.mu.Unlock()
+
+	return schema, nil
+}
+
+// Codec returns the Avro codec of the schema registered with the ID.
+func (r *SchemaRegistry) Codec(id int) (*goavro.Codec, error) {
+	r.mu.Lock()
+	codec, ok := r.codecs[id]
+	r.mu.Unlock()
+
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := r.Schema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.CodecFor(schema)
+}
+
+// CodecFor returns the Avro codec of a schema returned by the registry, codecs are cached by schema ID.
+func (r *SchemaRegistry) CodecFor(schema *RegisteredSchema) (*goavro.Codec, error) {
+	r.mu.Lock()
+	codec, ok := r.codecs[schema.ID]
+	r.mu.Unlock()
+
+	if ok {
+		return codec, nil
+	}
+
+	if schema.SchemaType != "" && schema.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("schema %d is a %s schema, not AVRO", schema.ID, schema.SchemaType)
+	}
+
+	codec, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Avro codec for schema %d: %v", schema.ID, err)
+	}
+
+	r.mu.Lock()
+	r.codecs[schema.ID] = codec
+	r.mu.Unlock()
+
+	return codec, nil
+}
+
+// get fetches the path and decodes the JSON response, retrying network errors and server errors.
+// Client errors such as 404 are returned at once since asking again gives the same answer.
+func (r *SchemaRegistry) get(path string, v interface{}) error {
+	var lastErr error
+
+	for attempt := 0; attempt < r.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+
+		retry, err := r.doGet(path, v)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+func (r *SchemaRegistry) doGet(path string, v interface{}) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url+path, nil)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("schema registry responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+	}
+
+	return false, json.Unmarshal(body, v)
+}
+
+func (r *SchemaRegistry) cachedSchemaPath(key string) string {
+	return filepath.Join(r.cacheDir, key+".json")
+}
+
+func (r *SchemaRegistry) readCachedSchema(key string) (*RegisteredSchema, error) {
+	if r.cacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(r.cachedSchemaPath(key))
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &RegisteredSchema{}
+	err = json.Unmarshal(data, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// writeCachedSchema is best effort, the schema is fetched again when it can't be cached.
+func (r *SchemaRegistry) writeCachedSchema(key string, schema *RegisteredSchema) {
+	if r.cacheDir == "" {
+		return
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return
+	}
+
+	if os.MkdirAll(r.cacheDir, 0o700) != nil {
+		return
+	}
+
+	// Written to a temporary file first so a concurrent reader never sees a partial schema
+	tmp, err := os.CreateTemp(r.cacheDir, "schema-*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	tmp.Close()
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if os.Rename(tmp.Name(), r.cachedSchemaPath(key)) != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// newFailingRegistry answers every request with the status.
+func newFailingRegistry(t *testing.T, status int) (*SchemaRegistry, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, `{"error_code":40403}`, status)
+	}))
+	t.Cleanup(server.Close)
+
+	registry := NewSchemaRegistry(&models.AvroConfig{
+		SCHEMA_URL:              server.URL,
+		SCHEMA_CACHE_DIR:        "-",
+		SCHEMA_REGISTRY_RETRIES: 2,
+	})
+
+	return registry, &requests
+}
+
+func TestSchemaRegistryCachesFailures(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int32
+	}{
+		{status: http.StatusNotFound, want: 1},
+		{status: http.StatusUnauthorized, want: 1},
+		{status: http.StatusInternalServerError, want: 2},
+	}
+
+	for _, tt := range tests {
+		registry, requests := newFailingRegistry(t, tt.status)
+
+		for i := 0; i < 3; i++ {
+			_, err := registry.Schema(7)
+			if err == nil {
+				t.Fatalf("Schema succeeded while the registry responded %d", tt.status)
+			}
+		}
+
+		if got := atomic.LoadInt32(requests); got != tt.want {
+			t.Errorf("registry responding %d got %d requests, want %d", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaRegistryRetriesAfterTTL(t *testing.T) {
+	registry, requests := newFailingRegistry(t, http.StatusNotFound)
+	registry.failureTTL = 10 * time.Millisecond
+
+	registry.Schema(7)
+	registry.Schema(7)
+	time.Sleep(20 * time.Millisecond)
+	registry.Schema(7)
+
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("registry got %d requests, want 2", got)
+	}
+}
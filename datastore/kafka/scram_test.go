@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/xdg-go/scram"
+)
+
+// converse runs the SCRAM exchange between the client and a server that only knows user:secret.
+func converse(t *testing.T, hash scram.HashGeneratorFcn, password string) error {
+	stored, err := hash.NewClient("user", "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials := stored.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+	server, err := hash.NewServer(func(user string) (scram.StoredCredentials, error) {
+		if user != "user" {
+			return scram.StoredCredentials{}, errors.New("unknown user")
+		}
+
+		return credentials, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &XDGSCRAMClient{HashGeneratorFcn: hash}
+	err = client.Begin("user", password, "")
+	if err != nil {
+		return err
+	}
+
+	conversation := server.NewConversation()
+	challenge := ""
+	for !client.Done() {
+		response, err := client.Step(challenge)
+		if err != nil {
+			return err
+		}
+
+		if conversation.Done() {
+			break
+		}
+
+		challenge, err = conversation.Step(response)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !conversation.Valid() {
+		return errors.New("the server did not accept the client")
+	}
+
+	return nil
+}
+
+func TestXDGSCRAMClient(t *testing.T) {
+	hashes := map[string]scram.HashGeneratorFcn{"SCRAM-SHA-256": SHA256, "SCRAM-SHA-512": SHA512}
+
+	for name, hash := range hashes {
+		err := converse(t, hash, "secret")
+		if err != nil {
+			t.Errorf("%s with the right password: %v", name, err)
+		}
+
+		err = converse(t, hash, "wrong")
+		if err == nil {
+			t.Errorf("%s with a wrong password was accepted", name)
+		}
+	}
+}
+
+// TestSCRAMBroker connects to a local broker with SCRAM, it only runs when SCRAM_TEST_HOSTS is set.
+// The broker needs a SASL_PLAINTEXT listener and a SCRAM user, eg: kafka-configs.sh --alter
+// --add-config 'SCRAM-SHA-512=[password=secret]' --entity-type users --entity-name user
+func TestSCRAMBroker(t *testing.T) {
+	hosts := os.Getenv("SCRAM_TEST_HOSTS")
+	if hosts == "" {
+		t.Skip("SCRAM_TEST_HOSTS is not set")
+	}
+
+	for _, mechanism := range []string{"SCRAM-SHA-256", "SCRAM-SHA-512"} {
+		config := KafkaConfig{
+			KAFKA_HOSTS:           hosts,
+			KAFKA_TOPIC:           os.Getenv("SCRAM_TEST_TOPIC"),
+			KAFKA_CONSUMER_OFFSET: "LATEST",
+			KAFKA_SASL_USER:       os.Getenv("SCRAM_TEST_USER"),
+			KAFKA_SASL_PASS:       os.Getenv("SCRAM_TEST_PASS"),
+			KAFKA_SASL_MECHANISM:  mechanism,
+			TLSConfig:             &models.TLSConfig{TLS_ENABLED: os.Getenv("SCRAM_TEST_TLS") != ""},
+		}
+
+		client, err := config.EstablishKafkaConn()
+		if err != nil {
+			t.Errorf("%s: %v", mechanism, err)
+			continue
+		}
+
+		client.Close()
+	}
+}
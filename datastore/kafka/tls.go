@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig builds the client TLS configuration from the TLS section.
+func (k *KafkaConfig) newTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         k.TLS_SERVER_NAME,
+		InsecureSkipVerify: k.TLS_INSECURE_SKIP_VERIFY,
+	}
+
+	if k.TLS_MIN_VERSION != "" {
+		version, ok := tlsVersions[strings.TrimSpace(k.TLS_MIN_VERSION)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %s, expected 1.0, 1.1, 1.2 or 1.3", k.TLS_MIN_VERSION)
+		}
+
+		config.MinVersion = version
+	}
+
+	if k.TLS_CA_FILE != "" {
+		pem, err := os.ReadFile(k.TLS_CA_FILE)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", k.TLS_CA_FILE)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if (k.TLS_CERT_FILE == "") != (k.TLS_KEY_FILE == "") {
+		return nil, errors.New("both the client certificate and key are required for mTLS")
+	}
+
+	if k.TLS_CERT_FILE != "" {
+		cert, err := tls.LoadX509KeyPair(k.TLS_CERT_FILE, k.TLS_KEY_FILE)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %v", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
@@ -2,21 +2,28 @@ package kafka
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/IBM/sarama"
 )
 
+// tokenTimeout bounds a token request so an unreachable AAD endpoint can't hang the broker handshake
+const tokenTimeout = 30 * time.Second
+
 type TokenProvider struct {
-	servicePrincipalToken *azidentity.ClientSecretCredential
-	audiences             []string
+	credential azcore.TokenCredential
+	audiences  []string
 }
 
-func NewTokenProvider(k *KafkaConfig) sarama.AccessTokenProvider {
+func NewTokenProvider(k *KafkaConfig) (sarama.AccessTokenProvider, error) {
 	audience := strings.Split(k.AAD_AUDIENCE, ",")
 
 	for i, aud := range audience {
@@ -25,23 +32,67 @@ func NewTokenProvider(k *KafkaConfig) sarama.AccessTokenProvider {
 		}
 	}
 
-	clientOpts := azcore.ClientOptions{Cloud: cloud.AzurePublic}
+	azureCloud, err := k.azureCloud()
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := azcore.ClientOptions{Cloud: azureCloud}
 
-	credential, err := azidentity.NewClientSecretCredential(k.AAD_TENANT_ID, k.AAD_APPLICATION_ID, k.AAD_APPLICATION_SECRET,
-		&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOpts})
+	credential, err := k.newAzureCredential(clientOpts)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("error creating azure credential: %v", err)
 	}
 
 	return &TokenProvider{
-		audiences:             audience,
-		servicePrincipalToken: credential,
-	}
+		audiences:  audience,
+		credential: credential,
+	}, nil
 }
 
 // Token returns a new *sarama.AccessToken or an error as appropriate.
 func (t *TokenProvider) Token() (*sarama.AccessToken, error) {
-	accessToken, _ := t.servicePrincipalToken.GetToken(context.TODO(), policy.TokenRequestOptions{Scopes: t.audiences})
+	ctx, cancel := context.WithTimeout(context.Background(), tokenTimeout)
+	defer cancel()
+
+	accessToken, err := t.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: t.audiences})
+	if err != nil {
+		return nil, describeAzureError(err)
+	}
 
 	return &sarama.AccessToken{Token: accessToken.Token}, nil
 }
+
+// aadErrorResponse is the error body returned by the AAD token endpoint
+type aadErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorCodes       []int  `json:"error_codes"`
+}
+
+// describeAzureError shortens an authentication failure to the AAD error code and description.
+func describeAzureError(err error) error {
+	var authErr *azidentity.AuthenticationFailedError
+	if !errors.As(err, &authErr) || authErr.RawResponse == nil {
+		return fmt.Errorf("error acquiring azure token: %v", err)
+	}
+
+	body, readErr := runtime.Payload(authErr.RawResponse)
+	if readErr != nil {
+		return fmt.Errorf("error acquiring azure token: %v", err)
+	}
+
+	var response aadErrorResponse
+	if json.Unmarshal(body, &response) != nil || response.Error == "" {
+		return fmt.Errorf("error acquiring azure token: %v", err)
+	}
+
+	// The description spans several lines with the trace and correlation IDs
+	description, _, _ := strings.Cut(response.ErrorDescription, "\r\n")
+
+	if len(response.ErrorCodes) > 0 && !strings.HasPrefix(description, "AADSTS") {
+		description = fmt.Sprintf("AADSTS%d: %s", response.ErrorCodes[0], description)
+	}
+
+	return fmt.Errorf("error acquiring azure token (%s): %s", response.Error, description)
+}
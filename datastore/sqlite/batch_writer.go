@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// BatchWriter buffers consumed messages and writes them to the records table
+// in batches, so a busy topic does not block on a database write per message.
+type BatchWriter struct {
+	store         *Store
+	queue         chan models.Message
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+
+	mu     sync.RWMutex // Held by Write while sending so Close can't close the queue under it
+	closed bool
+
+	errMu sync.Mutex
+	err   error // Failure of a batch, reported by the next Write
+}
+
+func NewBatchWriter(store *Store, batchSize int, flushInterval time.Duration) *BatchWriter {
+	return &BatchWriter{
+		store:         store,
+		queue:         make(chan models.Message, batchSize*10),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+// Write queues the message for the next batch, it waits while the queue is full so
+// no message is lost when the database falls behind. It returns the error of a batch
+// that couldn't be stored since the previous call.
+func (b *BatchWriter) Write(msg models.Message) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return errors.New("the record writer is closed, the message was not stored")
+	}
+
+	b.queue <- msg
+
+	return b.takeErr()
+}
+
+func (b *BatchWriter) takeErr() error {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+
+	err := b.err
+	b.err = nil
+
+	return err
+}
+
+// Run flushes the queued messages whenever the batch is full or the flush
+// interval has elapsed. It returns once Close has been called and the queue is drained.
+func (b *BatchWriter) Run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Message, 0, b.batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		err := b.store.CreateBatch(batch)
+		if err != nil {
+			b.errMu.Lock()
+			b.err = fmt.Errorf("error storing %d records: %v", len(batch), err)
+			b.errMu.Unlock()
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case msg, ok := <-b.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, msg)
+			if len(batch) >= b.batchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close stops accepting messages, waits for the pending batch to be written and returns its error.
+func (b *BatchWriter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+
+	<-b.done
+
+	return b.takeErr()
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store := CreateDB(filepath.Join(t.TempDir(), "records.db"))
+	t.Cleanup(func() { store.Close() })
+
+	err := store.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &store
+}
+
+func TestBatchWriterWriteAfterClose(t *testing.T) {
+	writer := NewBatchWriter(newTestStore(t), 10, time.Hour)
+	go writer.Run()
+
+	err := writer.Write(models.Message{Topic: "orders", Value: []byte(`{}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer.Close()
+
+	// A consumer still running during shutdown must not panic
+	if writer.Write(models.Message{Topic: "orders", Value: []byte(`{}`)}) == nil {
+		t.Error("Write after Close succeeded, want an error")
+	}
+
+	writer.Close()
+}
+
+func TestBatchWriterWaitsWhenFull(t *testing.T) {
+	store := newTestStore(t)
+
+	// Run is not started yet so nothing drains the queue of 10 messages
+	writer := NewBatchWriter(store, 1, time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 15; i++ {
+			writer.Write(models.Message{Topic: "orders", Offset: int64(i), Value: []byte(`{}`)})
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Write returned with a full queue, the message would be lost")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	go writer.Run()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write still blocked once the queue was drained")
+	}
+
+	err := writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := store.CountRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 15 {
+		t.Errorf("stored %d records, want 15", count)
+	}
+}
+
+func TestBatchWriterReportsFailedBatches(t *testing.T) {
+	store := newTestStore(t)
+	writer := NewBatchWriter(store, 1, time.Hour)
+	go writer.Run()
+
+	store.Close()
+
+	// The batch may fail before Write returns or only be reported by Close
+	writeErr := writer.Write(models.Message{Topic: "orders", Value: []byte(`{}`)})
+	closeErr := writer.Close()
+
+	if writeErr == nil && closeErr == nil {
+		t.Error("the batch stored after the database was closed failed silently")
+	}
+}
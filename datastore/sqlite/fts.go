@@ -0,0 +1,19 @@
+package sqlite
+
+import "strings"
+
+// fullTextTable indexes the message and headers of every record, it is kept in sync by triggers.
+// FTS4 is used since it is compiled into go-sqlite3, FTS5 needs the sqlite_fts5 build tag.
+const fullTextTable = "records_fts"
+
+// fullTextQuery quotes every term so ids such as order-42 are matched as
+// phrases instead of being parsed as FTS operators.
+func fullTextQuery(text string) string {
+	terms := strings.Fields(text)
+
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	return strings.Join(terms, " ")
+}
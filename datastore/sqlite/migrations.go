@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+)
+
+type migration struct {
+	version     int
+	description string
+	query       string
+}
+
+// migrations are applied in order and each version is applied only once.
+// Never edit a released migration, append a new one instead.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create records table",
+		// Builds before versioned migrations recreated the table on every launch,
+		// so an unversioned table never holds anything worth keeping.
+		query: `DROP TABLE IF EXISTS records;
+		CREATE TABLE records (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			topic TEXT NOT NULL,
+			partition INTEGER NOT NULL,
+			offset INTEGER NOT NULL,
+			headers TEXT,
+			message JSONB NOT NULL,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+	},
+	{
+		version:     2,
+		description: "add record index",
+		query:       `CREATE INDEX IF NOT EXISTS record_idx ON records(topic, partition, offset);`,
+	},
+	{
+		version:     3,
+		description: "store message keys",
+		query:       `ALTER TABLE records ADD COLUMN key TEXT;`,
+	},
+	{
+		version:     4,
+		description: "store undecodable messages",
+		query: `ALTER TABLE records ADD COLUMN decode_error TEXT;
+		ALTER TABLE records ADD COLUMN raw TEXT;`,
+	},
+	{
+		version:     5,
+		description: "index records for full-text search",
+		query: `CREATE VIRTUAL TABLE records_fts USING fts4(message, headers);
+		INSERT INTO records_fts (rowid, message, headers) SELECT id, message, headers FROM records;
+		CREATE TRIGGER records_fts_ai AFTER INSERT ON records BEGIN
+			INSERT INTO records_fts (rowid, message, headers) VALUES (new.id, new.message, new.headers);
+		END;
+		CREATE TRIGGER records_fts_ad AFTER DELETE ON records BEGIN
+			DELETE FROM records_fts WHERE rowid = old.id;
+		END;`,
+	},
+}
+
+func (s *Store) Migrate() error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	);`
+
+	_, err := s.DB.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = s.DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		err = s.apply(m)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) apply(m migration) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(m.query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?,?,?)`, m.version, m.description, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,235 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+// RecordFilter narrows down the stored records. Zero values are not applied.
+type RecordFilter struct {
+	Topic      string
+	Partition  *int32
+	FromOffset *int64
+	ToOffset   *int64
+	Since      time.Time
+	Until      time.Time
+	FullText   string
+	JSON       []utils.JSONPredicate
+	Limit      int
+	Offset     int
+}
+
+// QueryRecords pages through the stored records ordered by topic, partition and offset,
+// so the topic/partition/offset conditions are served by record_idx.
+func (s *Store) QueryRecords(f RecordFilter) ([]models.Record, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if f.Topic != "" {
+		conditions = append(conditions, "topic = ?")
+		args = append(args, f.Topic)
+	}
+
+	if f.Partition != nil {
+		conditions = append(conditions, "partition = ?")
+		args = append(args, *f.Partition)
+	}
+
+	if f.FromOffset != nil {
+		conditions = append(conditions, "offset >= ?")
+		args = append(args, *f.FromOffset)
+	}
+
+	if f.ToOffset != nil {
+		conditions = append(conditions, "offset <= ?")
+		args = append(args, *f.ToOffset)
+	}
+
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, f.Since.UTC())
+	}
+
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, f.Until.UTC())
+	}
+
+	if f.FullText != "" {
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT rowid FROM %[1]s WHERE %[1]s MATCH ?)", fullTextTable))
+		args = append(args, fullTextQuery(f.FullText))
+	}
+
+	for _, predicate := range f.JSON {
+		switch {
+		case predicate.Value == nil && predicate.Operator == "=":
+			conditions = append(conditions, "json_extract(message, ?) IS NULL")
+			args = append(args, predicate.Path)
+
+		case predicate.Value == nil:
+			conditions = append(conditions, "json_extract(message, ?) IS NOT NULL")
+			args = append(args, predicate.Path)
+
+		default:
+			// The operator comes from the whitelist in utils.ParseJSONPredicate
+			conditions = append(conditions, fmt.Sprintf("json_extract(message, ?) %s ?", predicate.Operator))
+			args = append(args, predicate.Path, predicate.Value)
+		}
+	}
+
+	query := `SELECT id, topic, partition, offset, key, headers, message, timestamp, decode_error, raw FROM records`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY topic, partition, offset LIMIT ? OFFSET ?"
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	args = append(args, limit, f.Offset)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+// SetSearch parses the search box input. Terms are joined with AND, the ones
+// starting with $ are JSON path predicates and the rest is full text
+// eg: order 42 AND $.order.storeId = '0042'
+func (f *RecordFilter) SetSearch(input string) error {
+	text := make([]string, 0)
+	f.JSON = nil
+
+	for _, term := range splitOnAnd(input) {
+		if term == "" {
+			continue
+		}
+
+		if !utils.IsJSONPredicate(term) {
+			text = append(text, term)
+			continue
+		}
+
+		predicate, err := utils.ParseJSONPredicate(term)
+		if err != nil {
+			return err
+		}
+
+		f.JSON = append(f.JSON, predicate)
+	}
+
+	f.FullText = strings.Join(text, " ")
+
+	return nil
+}
+
+// Search runs the search box input on top of the other filters.
+func (s *Store) Search(input string, f RecordFilter) ([]models.Record, error) {
+	err := f.SetSearch(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.QueryRecords(f)
+}
+
+// splitOnAnd splits on AND keywords that are not part of a single or double quoted value,
+// the quoted values are kept as typed.
+func splitOnAnd(input string) []string {
+	terms := make([]string, 0)
+	start := 0
+
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		switch c := input[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+
+		case c == '\'' || c == '"':
+			quote = c
+
+		case isAndKeyword(input, i):
+			terms = append(terms, strings.TrimSpace(input[start:i]))
+			start = i + len("AND")
+			i = start - 1
+		}
+	}
+
+	return append(terms, strings.TrimSpace(input[start:]))
+}
+
+// isAndKeyword reports whether a whitespace separated AND starts at i.
+func isAndKeyword(input string, i int) bool {
+	end := i + len("AND")
+	if end > len(input) || !strings.EqualFold(input[i:end], "AND") {
+		return false
+	}
+
+	return (i == 0 || isSpace(input[i-1])) && (end == len(input) || isSpace(input[end]))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func scanRecords(rows *sql.Rows) ([]models.Record, error) {
+	records := make([]models.Record, 0)
+
+	for rows.Next() {
+		var (
+			record      models.Record
+			key         sql.NullString
+			headers     sql.NullString
+			decodeError sql.NullString
+			raw         sql.NullString
+		)
+
+		err := rows.Scan(&record.ID, &record.Topic, &record.Partition, &record.Offset, &key, &headers, &record.Message, &record.Timestamp, &decodeError, &raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if decodeError.Valid {
+			record.DecodeError = decodeError.String
+		}
+
+		if raw.Valid {
+			record.Raw, err = base64.StdEncoding.DecodeString(raw.String)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if key.Valid {
+			record.Key = []byte(key.String)
+		}
+
+		if headers.Valid && headers.String != "" {
+			err = json.Unmarshal([]byte(headers.String), &record.Headers)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
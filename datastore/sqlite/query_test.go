@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+func TestSplitOnAnd(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"order 42", []string{"order 42"}},
+		{"order 42 AND $.order.storeId = '0042'", []string{"order 42", "$.order.storeId = '0042'"}},
+		{"a and b AnD c", []string{"a", "b", "c"}},
+		{"$.note = 'salt AND pepper'", []string{"$.note = 'salt AND pepper'"}},
+		{`$.note = "salt AND pepper" AND x`, []string{`$.note = "salt AND pepper"`, "x"}},
+		{"$.note = 'it''s AND more' AND x", []string{"$.note = 'it''s AND more'", "x"}},
+		{"$.note = 'two  spaces'", []string{"$.note = 'two  spaces'"}},
+		{"android AND sandy", []string{"android", "sandy"}},
+		{"AND x", []string{"", "x"}},
+	}
+
+	for _, tt := range tests {
+		got := splitOnAnd(tt.input)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitOnAnd(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFullTextAndJSON(t *testing.T) {
+	store := newTestStore(t)
+
+	err := store.CreateBatch([]models.Message{
+		{Topic: "orders", Offset: 1, Value: []byte(`{"note":"salt AND pepper","storeId":"0042"}`)},
+		{Topic: "orders", Offset: 2, Value: []byte(`{"note":"sugar","storeId":"0043"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		search string
+		want   []int64
+	}{
+		{"pepper", []int64{1}},
+		{"$.storeId = '0043'", []int64{2}},
+		{"$.note = 'salt AND pepper'", []int64{1}},
+		{"sugar AND $.storeId = '0042'", []int64{}},
+	}
+
+	for _, tt := range tests {
+		records, err := store.Search(tt.search, RecordFilter{})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.search, err)
+		}
+
+		got := make([]int64, 0)
+		for _, record := range records {
+			got = append(got, record.Offset)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = offsets %v, want %v", tt.search, got, tt.want)
+		}
+	}
+}
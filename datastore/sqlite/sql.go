@@ -2,27 +2,32 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"log"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/krogertechnology/data-tracker/models"
 )
 
 type Store struct {
 	*sql.DB
 }
 
-func CreateDB() Store {
-	db, err := sql.Open("sqlite3", "./records.db")
+func CreateDB(path string) Store {
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	return Store{db}
+	return Store{DB: db}
 }
 
+// DeleteExistingData removes the records stored by previous sessions but keeps the schema.
 func (s *Store) DeleteExistingData() error {
-	query := `DROP TABLE IF EXISTS records;`
+	query := `DELETE FROM records;`
 
 	_, err := s.DB.Exec(query)
 	if err != nil {
@@ -32,28 +37,21 @@ func (s *Store) DeleteExistingData() error {
 	return nil
 }
 
-func (s *Store) CreateTable() error {
-	query := `CREATE TABLE IF NOT EXISTS records (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		topic TEXT NOT NULL,
-		partition TEXT NOT NULL,
-		offset TEXT NOT NULL,
-        message JSONB NOT NULL,
-        timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
+func (s *Store) CountRecords() (int, error) {
+	var count int
 
-	_, err := s.DB.Exec(query)
+	err := s.DB.QueryRow(`SELECT COUNT(*) FROM records`).Scan(&count)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return count, nil
 }
 
-func (s *Store) AddIdx() error {
-	query := `CREATE INDEX IF NOT EXISTS record_idx ON records(topic, partition, offset);`
+func (s *Store) Create(topic, partition, message, offset string) error {
+	query := `INSERT INTO records (topic, partition, offset, message, timestamp) VALUES (?,?,?,?,?)`
 
-	_, err := s.DB.Exec(query)
+	_, err := s.DB.Exec(query, topic, partition, offset, message, time.Now().UTC())
 	if err != nil {
 		return err
 	}
@@ -61,13 +59,57 @@ func (s *Store) AddIdx() error {
 	return nil
 }
 
-func (s *Store) Create(topic, partition, message, offset string) error {
-	query := `INSERT INTO records (topic, partition, offset, message, timestamp) VALUES (?,?,?,?,?)`
+// CreateBatch inserts all the messages inside a single transaction.
+// The message value is expected to be the decoded JSON payload, undecodable
+// messages are stored with a null message and the base64 raw payload.
+func (s *Store) CreateBatch(messages []models.Message) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
 
-	_, err := s.DB.Exec(query, topic, partition, offset, message, time.Now())
+	query := `INSERT INTO records (topic, partition, offset, key, headers, message, timestamp, decode_error, raw) VALUES (?,?,?,?,?,?,?,?,?)`
+
+	stmt, err := tx.Prepare(query)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	defer stmt.Close()
+
+	for _, msg := range messages {
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		timestamp := msg.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+
+		var key interface{}
+		if msg.Key != nil {
+			key = string(msg.Key)
+		}
+
+		message := string(msg.Value)
+
+		var decodeError, raw interface{}
+		if msg.DecodeError != "" {
+			message = "null"
+			decodeError = msg.DecodeError
+			raw = base64.StdEncoding.EncodeToString(msg.Value)
+		}
+
+		_, err = stmt.Exec(msg.Topic, msg.Partition, msg.Offset, key, string(headers), message, timestamp.UTC(), decodeError, raw)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
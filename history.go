@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/krogertechnology/data-tracker/datastore/sqlite"
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+const historyPageSize = 20
+
+// CreateHistoryView builds the tab used to browse the messages stored in SQLite.
+func (g *GUI) CreateHistoryView() fyne.CanvasObject {
+	topicField := utils.CreateEntryWidget("Topic", true, false)
+	partitionField := utils.CreateEntryWidget("Partition", true, false)
+	fromOffsetField := utils.CreateEntryWidget("From Offset", true, false)
+	toOffsetField := utils.CreateEntryWidget("To Offset", true, false)
+	sinceField := utils.CreateEntryWidget("Since eg: 2025-01-02T15:04:05Z", true, false)
+	untilField := utils.CreateEntryWidget("Until eg: 2025-01-02T16:04:05Z", true, false)
+	searchField := utils.CreateEntryWidget("Search eg: order 42 AND $.order.storeId = '0042'", true, false)
+
+	resultWidget := utils.CreateTextWidget()
+	pageLabel := widget.NewLabel("")
+	page := 0
+
+	var prevButton, nextButton *widget.Button
+
+	load := func() {
+		filter, err := parseHistoryFilter(topicField.Text, partitionField.Text, fromOffsetField.Text, toOffsetField.Text, sinceField.Text, untilField.Text)
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+			return
+		}
+
+		err = filter.SetSearch(searchField.Text)
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+			return
+		}
+
+		filter.Limit = historyPageSize + 1
+		filter.Offset = page * historyPageSize
+
+		records, err := g.Store.QueryRecords(filter)
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+			return
+		}
+
+		hasNext := len(records) > historyPageSize
+		if hasNext {
+			records = records[:historyPageSize]
+		}
+
+		if len(records) == 0 {
+			resultWidget.SetText("No stored messages match the filters\n")
+		} else {
+			resultWidget.SetText(formatRecords(records))
+		}
+
+		pageLabel.SetText(fmt.Sprintf("Page %d", page+1))
+
+		if page > 0 {
+			prevButton.Enable()
+		} else {
+			prevButton.Disable()
+		}
+
+		if hasNext {
+			nextButton.Enable()
+		} else {
+			nextButton.Disable()
+		}
+	}
+
+	searchButton := widget.NewButtonWithIcon("Search", theme.SearchIcon(), func() {
+		page = 0
+		load()
+	})
+
+	searchField.OnSubmitted = func(string) {
+		page = 0
+		load()
+	}
+
+	prevButton = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		page -= 1
+		load()
+	})
+
+	nextButton = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		page += 1
+		load()
+	})
+
+	prevButton.Disable()
+	nextButton.Disable()
+
+	filters := container.NewVBox(
+		container.NewGridWithColumns(2, topicField, partitionField, fromOffsetField, toOffsetField, sinceField, untilField),
+		searchField,
+		container.NewHBox(searchButton, layout.NewSpacer(), prevButton, pageLabel, nextButton),
+	)
+
+	return container.NewBorder(filters, nil, nil, nil, container.NewScroll(resultWidget))
+}
+
+func parseHistoryFilter(topic, partition, fromOffset, toOffset, since, until string) (sqlite.RecordFilter, error) {
+	filter := sqlite.RecordFilter{Topic: strings.TrimSpace(topic)}
+
+	if partition = strings.TrimSpace(partition); partition != "" {
+		p, err := strconv.ParseInt(partition, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid partition: %v", partition)
+		}
+
+		p32 := int32(p)
+		filter.Partition = &p32
+	}
+
+	if fromOffset = strings.TrimSpace(fromOffset); fromOffset != "" {
+		o, err := strconv.ParseInt(fromOffset, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from offset: %v", fromOffset)
+		}
+
+		filter.FromOffset = &o
+	}
+
+	if toOffset = strings.TrimSpace(toOffset); toOffset != "" {
+		o, err := strconv.ParseInt(toOffset, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to offset: %v", toOffset)
+		}
+
+		filter.ToOffset = &o
+	}
+
+	if since = strings.TrimSpace(since); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since timestamp: %v", since)
+		}
+
+		filter.Since = t
+	}
+
+	if until = strings.TrimSpace(until); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until timestamp: %v", until)
+		}
+
+		filter.Until = t
+	}
+
+	return filter, nil
+}
+
+func formatRecords(records []models.Record) string {
+	var sb strings.Builder
+
+	for _, record := range records {
+		sb.WriteString(fmt.Sprintf("Topic %v, Partition %v with Offset %v at %v\n", record.Topic, record.Partition, record.Offset, record.Timestamp.Local().Format(time.RFC3339)))
+
+		if record.Key != nil {
+			sb.WriteString(fmt.Sprintf("Key: %s\n", record.Key))
+		}
+
+		if record.DecodeError != "" {
+			sb.WriteString(fmt.Sprintf("!!! DECODE ERROR: %s\n", record.DecodeError))
+			sb.WriteString(fmt.Sprintf("Raw payload (%d bytes): %s\n\n", len(record.Raw), base64.StdEncoding.EncodeToString(record.Raw)))
+			continue
+		}
+
+		data, err := utils.FormatJSONString([]byte(record.Message))
+		if err != nil {
+			data = []byte(record.Message)
+		}
+
+		sb.Write(data)
+		sb.WriteString("\n\n")
+	}
+
+	return sb.String()
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -12,20 +17,51 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/krogertechnology/data-tracker/api"
+	datastore "github.com/krogertechnology/data-tracker/datastore/kafka"
+	"github.com/krogertechnology/data-tracker/datastore/sqlite"
 	"github.com/krogertechnology/data-tracker/models"
 	"github.com/krogertechnology/data-tracker/service"
 	"github.com/krogertechnology/data-tracker/utils"
 )
 
 func main() {
+	// data-tracker tail streams a connection to stdout without the GUI
+	if len(os.Args) > 1 && os.Args[1] == "tail" {
+		err := runTail(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "data-tracker tail: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	clearMsgAfter := 10
 
-	// Create and truncate table
-	err := service.Migrations()
+	dbPath := flag.String("db", "./records.db", "Path of the SQLite database used to store consumed messages")
+	apiAddr := flag.String("api", "", "Serve the HTTP and WebSocket API on this localhost address eg: 127.0.0.1:8765, disabled when empty")
+	flag.Parse()
+
+	db := sqlite.CreateDB(*dbPath)
+	defer db.Close()
+
+	// Bring the schema up to date without losing the previous session
+	err := service.Migrations(&db)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	previousRecords, err := db.CountRecords()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Every consumed message is persisted in batches
+	recorder := sqlite.NewBatchWriter(&db, 100, time.Second)
+	go recorder.Run()
+	defer recorder.Close()
+
 	a := app.NewWithID("com.eventhub.datatracker")
 	window := a.NewWindow("Data Tracker v0.1")
 
@@ -36,21 +72,42 @@ func main() {
 	widgetMap := make(map[string]*widget.Label, 0)
 	creationChan := make(chan models.Config)
 
-	gui := GUI{widgetMap, tabBar, window}
+	gui := GUI{WidgetMap: widgetMap, TabBar: tabBar, Window: window, Store: &db, Recorder: recorder, ClearMsgAfter: clearMsgAfter, Hub: api.NewHub()}
+
+	// Runs before the recorder is closed so no message is stored once it is
+	defer gui.DisconnectAll()
+
+	if *apiAddr != "" {
+		gui.API = api.NewServer(&db, gui.Hub, gui.Connect)
+
+		go func() {
+			err := gui.API.ListenAndServe(*apiAddr)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("error serving the API: %v", err), window)
+			}
+		}()
+	}
 
 	form := gui.CreateKafkaConfigForm(creationChan)
-	formContainer := container.New(layout.NewCenterLayout(), form)
+	formContainer := container.NewVScroll(container.New(layout.NewCenterLayout(), form))
 
 	tabHeader := container.NewTabItemWithIcon("Add Eventhub", theme.ContentAddIcon(), formContainer)
 	tabBar.Append(tabHeader)
 
-	go gui.UpdateUIWithNewConnection(creationChan, clearMsgAfter)
+	historyTab := container.NewTabItemWithIcon("History", theme.HistoryIcon(), gui.CreateHistoryView())
+	tabBar.Append(historyTab)
+
+	go gui.UpdateUIWithNewConnection(creationChan)
 
 	window.SetPadded(true)
 	window.Resize(fyne.NewSize(770, 750))
 	window.SetContent(tabBar)
 	window.CenterOnScreen()
 
+	if previousRecords > 0 {
+		gui.ShowSessionChoice(previousRecords)
+	}
+
 	window.ShowAndRun()
 }
 
@@ -58,53 +115,167 @@ type GUI struct {
 	WidgetMap map[string]*widget.Label
 	TabBar    *container.AppTabs
 	Window    fyne.Window
+	Store     *sqlite.Store
+	Recorder  *sqlite.BatchWriter
+	mu        sync.Mutex // Guards WidgetMap and connections
+
+	connections []*service.KafkaOBJ
+
+	ClearMsgAfter int
+	Hub           *api.Hub    // Streams the decoded messages to the API clients
+	API           *api.Server // Nil unless the API is enabled with -api
 }
 
-func (g *GUI) UpdateUIWithNewConnection(creationChan chan models.Config, clearMsgAfter int) {
-	for config := range creationChan {
-		kafkaOBJ := service.NewKafkaObj(config, clearMsgAfter)
+// ShowSessionChoice lets the user continue with the messages stored by the previous session or start fresh.
+func (g *GUI) ShowSessionChoice(previousRecords int) {
+	message := fmt.Sprintf("%d messages were captured in a previous session.\nDo you want to continue with them or start fresh?", previousRecords)
+
+	dialog.ShowCustomConfirm("Previous Session Found", "Continue", "Start Fresh", widget.NewLabel(message), func(continueSession bool) {
+		if continueSession {
+			return
+		}
 
-		client, err := kafkaOBJ.SetupEventhub()
+		err := g.Store.DeleteExistingData()
 		if err != nil {
 			dialog.ShowError(err, g.Window)
-			continue
 		}
+	}, g.Window)
+}
 
-		if err := g.AddEventhubUI(kafkaOBJ); err != nil {
+func (g *GUI) UpdateUIWithNewConnection(creationChan chan models.Config) {
+	for config := range creationChan {
+		kafkaOBJ, err := g.Connect(config)
+		if err != nil {
 			dialog.ShowError(err, g.Window)
 			continue
 		}
 
-		go func() {
-			err := kafkaOBJ.Read(client)
-			if err != nil {
-				dialog.ShowError(err, g.Window)
-			}
-		}()
+		if g.API != nil {
+			g.API.Register(kafkaOBJ)
+		}
+	}
+}
 
-		go func() {
-			err := kafkaOBJ.Listen(g.WidgetMap)
-			if err != nil {
-				dialog.ShowError(err, g.Window)
-			}
-		}()
+// Connect opens the tabs of a new connection and starts consuming, it is shared by the form and the API.
+func (g *GUI) Connect(config models.Config) (*service.KafkaOBJ, error) {
+	kafkaOBJ := service.NewKafkaObj(config)
+
+	client, err := kafkaOBJ.SetupEventhub()
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := g.AddEventhubUI(kafkaOBJ)
+	if err != nil {
+		kafkaOBJ.Disconnect()
+		return nil, err
+	}
+
+	go func() {
+		err := kafkaOBJ.Read(client)
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+		}
+	}()
+
+	// Every topic is displayed in its tab, stored and streamed to the API clients
+	sinks := make(map[string]service.MessageSink, 0)
+	for topic, label := range labels {
+		sinks[topic] = service.NewMultiSink(service.NewLabelSink(label, g.ClearMsgAfter), service.NewStoreSink(g.Recorder), g.Hub.Sink(kafkaOBJ))
 	}
+
+	go kafkaOBJ.Listen(sinks)
+
+	return kafkaOBJ, nil
 }
 
-func (g *GUI) AddEventhubUI(k *service.KafkaOBJ) error {
+func (g *GUI) AddEventhubUI(k *service.KafkaOBJ) (map[string]*widget.Label, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	seen := make(map[string]bool, 0)
 	for _, topic := range k.Configs.TOPICS {
-		_, ok := k.DataChannel[topic]
-		if ok {
-			return fmt.Errorf("same topic %s can't be added twice", topic)
+		_, ok := g.WidgetMap[topic]
+		if ok || seen[topic] {
+			return nil, fmt.Errorf("same topic %s can't be added twice", topic)
 		}
 
+		seen[topic] = true
+	}
+
+	g.connections = append(g.connections, k)
+
+	labels := make(map[string]*widget.Label, 0)
+	tabs := make([]*container.TabItem, 0)
+	pauseButtons := make([]*widget.Button, 0)
+
+	// Every tab of the connection shares the same consumer
+	togglePause := func() {
+		if k.Paused() {
+			k.Resume()
+		} else {
+			k.Pause()
+		}
+
+		for _, b := range pauseButtons {
+			if k.Paused() {
+				b.SetText("Resume")
+				b.SetIcon(theme.MediaPlayIcon())
+			} else {
+				b.SetText("Pause")
+				b.SetIcon(theme.MediaPauseIcon())
+			}
+		}
+	}
+
+	disconnect := func() {
+		message := fmt.Sprintf("Disconnect from %s?", strings.Join(k.Configs.TOPICS, ", "))
+		dialog.ShowConfirm("Disconnect", message, func(confirmed bool) {
+			if confirmed {
+				go g.RemoveEventhubUI(k, tabs)
+			}
+		}, g.Window)
+	}
+
+	for _, topic := range k.Configs.TOPICS {
 		textWidget := utils.CreateTextWidget()
 
 		clearButton := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), func() {
 			textWidget.SetText("")
 		})
 
-		c := container.NewStack(container.NewScroll(textWidget), container.NewBorder(nil, container.NewHBox(&layout.Spacer{}, clearButton), nil, nil))
+		topic := topic
+
+		// Switching the format applies to the next messages of the topic
+		formatSelect := widget.NewSelect(datastore.DecoderNames(), nil)
+		formatSelect.SetSelected(k.DataFormat(topic))
+		formatSelect.OnChanged = func(format string) {
+			if format == k.DataFormat(topic) {
+				return
+			}
+
+			err := k.SetDataFormat(topic, format)
+			if err != nil {
+				dialog.ShowError(err, g.Window)
+				formatSelect.SetSelected(k.DataFormat(topic))
+			}
+		}
+
+		compressionSelect := widget.NewSelect(datastore.Compressions, nil)
+		compressionSelect.SetSelected(k.Compression(topic))
+		compressionSelect.OnChanged = func(compression string) {
+			err := k.SetCompression(topic, compression)
+			if err != nil {
+				dialog.ShowError(err, g.Window)
+			}
+		}
+
+		pauseButton := widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), togglePause)
+		disconnectButton := widget.NewButtonWithIcon("Disconnect", theme.CancelIcon(), disconnect)
+		pauseButtons = append(pauseButtons, pauseButton)
+
+		buttons := container.NewHBox(&layout.Spacer{}, compressionSelect, formatSelect, pauseButton, disconnectButton, clearButton)
+		c := container.NewStack(container.NewScroll(textWidget), container.NewBorder(nil, buttons, nil, nil))
 		tabItem := container.NewTabItemWithIcon(topic, theme.ComputerIcon(), c)
 
 		defaultText := fmt.Sprintf("Establishing a connection with %s\n", topic)
@@ -114,78 +285,185 @@ func (g *GUI) AddEventhubUI(k *service.KafkaOBJ) error {
 
 		g.TabBar.Append(tabItem)
 		g.WidgetMap[topic] = textWidget
+		labels[topic] = textWidget
+		tabs = append(tabs, tabItem)
+	}
+
+	produceTab := container.NewTabItemWithIcon("Produce "+strings.Join(k.Configs.TOPICS, ", "), theme.MailSendIcon(), g.CreateProduceView(k))
+	g.TabBar.Append(produceTab)
+	tabs = append(tabs, produceTab)
+
+	return labels, nil
+}
+
+// DisconnectAll stops every connection, the consumers are done once it returns.
+func (g *GUI) DisconnectAll() {
+	g.mu.Lock()
+	connections := g.connections
+	g.connections = nil
+	g.mu.Unlock()
+
+	for _, k := range connections {
+		k.Disconnect()
+	}
+}
+
+// RemoveEventhubUI disconnects and removes the tabs so the topics can be added again.
+func (g *GUI) RemoveEventhubUI(k *service.KafkaOBJ, tabs []*container.TabItem) {
+	err := k.Disconnect()
+	if err != nil {
+		dialog.ShowError(err, g.Window)
+	}
+
+	g.mu.Lock()
+	for _, topic := range k.Configs.TOPICS {
+		delete(g.WidgetMap, topic)
 	}
 
-	return nil
+	for i, connection := range g.connections {
+		if connection == k {
+			g.connections = append(g.connections[:i], g.connections[i+1:]...)
+			break
+		}
+	}
+	g.mu.Unlock()
+
+	for _, tab := range tabs {
+		g.TabBar.Remove(tab)
+	}
 }
 
 func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Container {
 	form := widget.NewForm()
 
-	kafkaHostField := utils.CreateEntryWidget("Enter Your Kafka Host Name", true, false)
+	kafkaHostField := utils.CreateEntryWidget("Enter Your Kafka Hosts eg: broker1:9092,broker2:9092", true, false)
 	kafkaTopicField := utils.CreateEntryWidget("Enter Your Kafka Topic Name", true, false)
 	kafkaConsumerIdField := utils.CreateEntryWidget("Enter Your Kafka Consumer Group ID", true, false)
-	kafkaConsumerOffsetField := utils.CreateEntryWidget("Enter Your Consumer Offset eg: Latest, Oldest", true, false)
+	kafkaConsumerOffsetField := utils.CreateEntryWidget("Enter Your Consumer Offset eg: Latest, Oldest, Latest-100, 42, 2025-01-02T15:04:05Z", true, false)
+	kafkaPartitionsField := utils.CreateEntryWidget("Optional, Partitions To Consume eg: 0,3,7-9 (Single Topic Only)", true, false)
 	kafkaSASLUserField := utils.CreateEntryWidget("Enter Your SASL Username", false, false)
 	kafkaSASLPasswordField := utils.CreateEntryWidget("Enter Your SASL Password", false, true)
 	azureAudienceField := utils.CreateEntryWidget("Enter Your Azure Audience", false, false)
 	azureTenantIdField := utils.CreateEntryWidget("Enter Your Azure TenantID", false, false)
 	azureApplicationIdField := utils.CreateEntryWidget("Enter Your Azure ApplicationID", false, false)
 	azureApplicationSecretField := utils.CreateEntryWidget("Enter Your Azure Application Secret", false, true)
-	avroSchemaUrlField := utils.CreateEntryWidget("Enter Your Avro Schema URL", false, false)
-	avroSchemaVersionField := utils.CreateEntryWidget("Enter Your Avro Schema Version", false, false)
+	azureCertificateField := utils.CreateEntryWidget("Enter Your Azure Certificate Path (PEM or PKCS#12)", false, false)
+	azureCertificatePasswordField := utils.CreateEntryWidget("Optional, Azure Certificate Password", false, true)
+	azureManagedIdentityClientIdField := utils.CreateEntryWidget("Optional, User Assigned Identity ClientID", false, false)
+	azureFederatedTokenFileField := utils.CreateEntryWidget("Optional, Federated Token File, Defaults To AZURE_FEDERATED_TOKEN_FILE", false, false)
+	azureCredentialTypeSelect := widget.NewSelect(datastore.AzureCredentialTypes, nil)
+	azureCredentialTypeSelect.SetSelected(datastore.AzureClientSecret)
+	azureCredentialTypeSelect.Disable()
+	azureCloudSelect := widget.NewSelect(datastore.AzureClouds, nil)
+	azureCloudSelect.SetSelected(datastore.AzureCloudPublic)
+	azureCloudSelect.Disable()
+	avroSchemaUrlField := utils.CreateEntryWidget("Enter Your Schema Registry URL", false, false)
+	avroSchemaVersionField := utils.CreateEntryWidget("Optional, Subject Version eg: 3, Defaults To latest", false, false)
+	avroSchemaSubjectField := utils.CreateEntryWidget("Enter Your Schema Subject eg: orders-value", false, false)
+	avroRegistryUserField := utils.CreateEntryWidget("Optional, Schema Registry Username", false, false)
+	avroRegistryPasswordField := utils.CreateEntryWidget("Optional, Schema Registry Password", false, true)
+	avroRegistryTokenField := utils.CreateEntryWidget("Optional, Schema Registry Bearer Token", false, true)
+	avroSchemaFileField := utils.CreateEntryWidget("Enter Your Local Avro Schema File (.avsc)", false, false)
+	protobufDescriptorSetField := utils.CreateEntryWidget("Optional, Descriptor Set When Not Using The Registry", false, false)
+	protobufMessageTypeField := utils.CreateEntryWidget("Optional, Message Type For The Descriptor Set eg: orders.v1.Order", false, false)
+
+	krb5ConfigField := utils.CreateEntryWidget("Enter Your krb5.conf Path", false, false)
+	krb5RealmField := utils.CreateEntryWidget("Optional, Kerberos Realm When Not In The Principal", false, false)
+	krb5ServiceNameField := utils.CreateEntryWidget("Optional, Broker Service Name eg: kafka", false, false)
+	krb5PrincipalField := utils.CreateEntryWidget("Enter Your Kerberos Principal eg: user@EXAMPLE.COM", false, false)
+	krb5KeytabField := utils.CreateEntryWidget("Enter Your Keytab Path", false, false)
+	krb5CCacheField := utils.CreateEntryWidget("Enter Your Credential Cache Path eg: /tmp/krb5cc_1000", false, false)
+	krb5DisableFastCheck := widget.NewCheck("Disable PA-FX-FAST (Active Directory)", nil)
+	krb5AuthTypeRadio := widget.NewRadioGroup([]string{"KEYTAB", "CCACHE"}, nil)
+	krb5AuthTypeRadio.Horizontal = true
+	krb5AuthTypeRadio.Disable()
+	krb5DisableFastCheck.Disable()
+
+	tlsCAField := utils.CreateEntryWidget("Optional, CA Certificate PEM File", false, false)
+	tlsCertField := utils.CreateEntryWidget("Optional, Client Certificate PEM File For mTLS", false, false)
+	tlsKeyField := utils.CreateEntryWidget("Optional, Client Key PEM File For mTLS", false, false)
+	tlsServerNameField := utils.CreateEntryWidget("Optional, Server Name Override", false, false)
+	tlsMinVersionSelect := widget.NewSelect([]string{"1.0", "1.1", "1.2", "1.3"}, nil)
+	tlsMinVersionSelect.PlaceHolder = "Optional, Minimum TLS Version"
+	tlsSkipVerifyCheck := widget.NewCheck("Skip Certificate Verification (Dev Clusters Only)", nil)
+
+	tlsEnabledCheck := widget.NewCheck("Enable TLS", func(enabled bool) {
+		utils.SetEnabled(enabled, tlsCAField, tlsCertField, tlsKeyField, tlsServerNameField, tlsMinVersionSelect, tlsSkipVerifyCheck)
+		form.Refresh()
+	})
+	tlsEnabledCheck.OnChanged(false)
+
+	// Only the fields used by the selected credential type are enabled
+	setAzureFieldsEnabled := func(enabled bool) {
+		credentialType := azureCredentialTypeSelect.Selected
+
+		utils.SetEnabled(enabled, azureAudienceField, azureCredentialTypeSelect, azureCloudSelect)
+		utils.SetEnabled(enabled && credentialType != datastore.AzureManagedIdentity, azureTenantIdField)
+		utils.SetEnabled(enabled && (credentialType == datastore.AzureClientSecret || credentialType == datastore.AzureClientCertificate || credentialType == datastore.AzureWorkloadIdentity), azureApplicationIdField)
+		utils.SetEnabled(enabled && credentialType == datastore.AzureClientSecret, azureApplicationSecretField)
+		utils.SetEnabled(enabled && credentialType == datastore.AzureClientCertificate, azureCertificateField, azureCertificatePasswordField)
+		utils.SetEnabled(enabled && credentialType == datastore.AzureManagedIdentity, azureManagedIdentityClientIdField)
+		utils.SetEnabled(enabled && credentialType == datastore.AzureWorkloadIdentity, azureFederatedTokenFileField)
+	}
 
 	// default value
 	kafkaSASLUserField.SetText("$ConnectionString")
 
-	saslMechanismOptions := []string{"PLAINTEXT", "SASL/PLAIN", "OUTHBEARER"}
+	saslMechanismOptions := []string{"PLAINTEXT", "SASL/PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512", "OUTHBEARER", "GSSAPI"}
 	saslMechanism := widget.NewRadioGroup(saslMechanismOptions, func(selected string) {
+		// Default TLS for the mechanism, it can still be changed separately eg: SSL without SASL
+		if selected != "" {
+			tlsEnabledCheck.SetChecked(selected != "PLAINTEXT")
+		}
+
+		setAzureFieldsEnabled(selected == "OUTHBEARER")
+		utils.SetEnabled(selected == "GSSAPI", krb5ConfigField, krb5RealmField, krb5ServiceNameField, krb5PrincipalField, krb5AuthTypeRadio, krb5KeytabField, krb5CCacheField, krb5DisableFastCheck)
+
 		switch selected {
 		case "OUTHBEARER":
 			kafkaSASLUserField.Disable()
 			kafkaSASLPasswordField.Disable()
-			azureAudienceField.Enable()
-			azureTenantIdField.Enable()
-			azureApplicationIdField.Enable()
-			azureApplicationSecretField.Enable()
 			form.Refresh()
 
 		case "PLAINTEXT":
 			kafkaSASLUserField.Disable()
 			kafkaSASLPasswordField.Disable()
-			azureAudienceField.Disable()
-			azureTenantIdField.Disable()
-			azureApplicationIdField.Disable()
-			azureApplicationSecretField.Disable()
 			form.Refresh()
 
-		case "SASL/PLAIN":
+		case "SASL/PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
 			kafkaSASLUserField.Enable()
 			kafkaSASLPasswordField.Enable()
-			azureAudienceField.Disable()
-			azureTenantIdField.Disable()
-			azureApplicationIdField.Disable()
-			azureApplicationSecretField.Disable()
+			form.Refresh()
+
+		case "GSSAPI":
+			kafkaSASLUserField.Disable()
+			kafkaSASLPasswordField.Disable()
 			form.Refresh()
 		}
 
 	})
 	saslMechanism.Horizontal = true
 
-	dataFormats := []string{"JSON", "AVRO"}
-	dataFormatRadio := widget.NewRadioGroup(dataFormats, func(selected string) {
-		switch selected {
-		case "JSON":
-			avroSchemaUrlField.Disable()
-			avroSchemaVersionField.Disable()
-			form.Refresh()
+	azureCredentialTypeSelect.OnChanged = func(string) {
+		setAzureFieldsEnabled(saslMechanism.Selected == "OUTHBEARER")
+		form.Refresh()
+	}
 
-		case "AVRO":
-			avroSchemaUrlField.Enable()
-			avroSchemaVersionField.Enable()
-			form.Refresh()
-		}
+	// AUTO tries every format that is configured so all the fields are enabled
+	dataFormatSelect := widget.NewSelect(datastore.DecoderNames(), func(selected string) {
+		auto := selected == datastore.DecoderAuto
+		registry := auto || selected == "AVRO" || selected == "AVRO_SUBJECT" || selected == "PROTOBUF"
+
+		utils.SetEnabled(registry, avroSchemaUrlField, avroRegistryUserField, avroRegistryPasswordField, avroRegistryTokenField)
+		utils.SetEnabled(auto || selected == "AVRO_SUBJECT", avroSchemaSubjectField, avroSchemaVersionField)
+		utils.SetEnabled(auto || selected == "AVRO_LOCAL", avroSchemaFileField)
+		utils.SetEnabled(auto || selected == "PROTOBUF", protobufDescriptorSetField, protobufMessageTypeField)
+		form.Refresh()
 	})
+	dataFormatSelect.SetSelected(datastore.DecoderAuto)
+
+	compressionSelect := widget.NewSelect(datastore.Compressions, nil)
+	compressionSelect.SetSelected(datastore.CompressionAuto)
 
 	// Set up form submit button and collect the data
 	submitButton := widget.NewButton("Connect", func() {
@@ -194,19 +472,57 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 		}
 
 		if saslMechanism.Selected == "OUTHBEARER" {
-			if azureAudienceField.Text == "" || azureTenantIdField.Text == "" || azureApplicationIdField.Text == "" || azureApplicationSecretField.Text == "" {
+			if azureAudienceField.Text == "" {
 				return
 			}
+
+			switch azureCredentialTypeSelect.Selected {
+			case datastore.AzureClientSecret:
+				if azureTenantIdField.Text == "" || azureApplicationIdField.Text == "" || azureApplicationSecretField.Text == "" {
+					return
+				}
+
+			case datastore.AzureClientCertificate:
+				if azureTenantIdField.Text == "" || azureApplicationIdField.Text == "" || azureCertificateField.Text == "" {
+					return
+				}
+			}
 		}
 
-		if saslMechanism.Selected == "SASL/PLAIN" {
+		if saslMechanism.Selected == "SASL/PLAIN" || strings.HasPrefix(saslMechanism.Selected, "SCRAM-") {
 			if kafkaSASLUserField.Text == "" || kafkaSASLPasswordField.Text == "" {
 				return
 			}
 		}
 
-		if dataFormatRadio.Selected == "AVRO" {
-			if avroSchemaUrlField.Text == "" || avroSchemaVersionField.Text == "" {
+		if saslMechanism.Selected == "GSSAPI" {
+			if krb5ConfigField.Text == "" || krb5PrincipalField.Text == "" || krb5AuthTypeRadio.Selected == "" {
+				return
+			}
+
+			if (krb5AuthTypeRadio.Selected == "KEYTAB" && krb5KeytabField.Text == "") || (krb5AuthTypeRadio.Selected == "CCACHE" && krb5CCacheField.Text == "") {
+				return
+			}
+		}
+
+		if dataFormatSelect.Selected == "AVRO" {
+			if avroSchemaUrlField.Text == "" {
+				return
+			}
+		}
+
+		if dataFormatSelect.Selected == "AVRO_SUBJECT" {
+			if avroSchemaUrlField.Text == "" || avroSchemaSubjectField.Text == "" {
+				return
+			}
+		}
+
+		if dataFormatSelect.Selected == "AVRO_LOCAL" && avroSchemaFileField.Text == "" {
+			return
+		}
+
+		if dataFormatSelect.Selected == "PROTOBUF" {
+			if avroSchemaUrlField.Text == "" && (protobufDescriptorSetField.Text == "" || protobufMessageTypeField.Text == "") {
 				return
 			}
 		}
@@ -216,24 +532,61 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 			KAFKA_TOPIC:             kafkaTopicField.Text,
 			KAFKA_CONSUMER_GROUP_ID: kafkaConsumerIdField.Text,
 			KAFKA_CONSUMER_OFFSET:   kafkaConsumerOffsetField.Text,
+			KAFKA_PARTITIONS:        kafkaPartitionsField.Text,
 			KAFKA_SASL_MECHANISM:    saslMechanism.Selected,
 			KAFKA_SASL_USER:         kafkaSASLUserField.Text,
 			KAFKA_SASL_PASS:         kafkaSASLPasswordField.Text,
+			DATA_FORMAT:             dataFormatSelect.Selected,
+			COMPRESSION:             compressionSelect.Selected,
 			AZURE_CONFIGS: &models.AzureConfig{
 				AAD_AUDIENCE:           azureAudienceField.Text,
 				AAD_TENANT_ID:          azureTenantIdField.Text,
 				AAD_APPLICATION_ID:     azureApplicationIdField.Text,
 				AAD_APPLICATION_SECRET: azureApplicationSecretField.Text,
+
+				AAD_CREDENTIAL_TYPE:            azureCredentialTypeSelect.Selected,
+				AAD_CERTIFICATE_PATH:           azureCertificateField.Text,
+				AAD_CERTIFICATE_PASSWORD:       azureCertificatePasswordField.Text,
+				AAD_MANAGED_IDENTITY_CLIENT_ID: azureManagedIdentityClientIdField.Text,
+				AAD_FEDERATED_TOKEN_FILE:       azureFederatedTokenFileField.Text,
+				AAD_CLOUD:                      azureCloudSelect.Selected,
 			},
 			AVRO_CONFIGS: &models.AvroConfig{
 				SCHEMA_URL:     avroSchemaUrlField.Text,
 				SCHEMA_VERSION: avroSchemaVersionField.Text,
+				SCHEMA_FILE:    avroSchemaFileField.Text,
+				SCHEMA_SUBJECT: avroSchemaSubjectField.Text,
+
+				SCHEMA_REGISTRY_USER:  avroRegistryUserField.Text,
+				SCHEMA_REGISTRY_PASS:  avroRegistryPasswordField.Text,
+				SCHEMA_REGISTRY_TOKEN: avroRegistryTokenField.Text,
+			},
+			PROTOBUF_CONFIGS: &models.ProtobufConfig{
+				DESCRIPTOR_SET_PATH: protobufDescriptorSetField.Text,
+				MESSAGE_TYPE:        protobufMessageTypeField.Text,
+			},
+			KERBEROS_CONFIGS: &models.KerberosConfig{
+				KRB5_CONFIG_PATH:        krb5ConfigField.Text,
+				KRB5_REALM:              krb5RealmField.Text,
+				KRB5_SERVICE_NAME:       krb5ServiceNameField.Text,
+				KRB5_PRINCIPAL:          krb5PrincipalField.Text,
+				KRB5_AUTH_TYPE:          krb5AuthTypeRadio.Selected,
+				KRB5_KEYTAB_PATH:        krb5KeytabField.Text,
+				KRB5_CCACHE_PATH:        krb5CCacheField.Text,
+				KRB5_DISABLE_PA_FX_FAST: krb5DisableFastCheck.Checked,
+			},
+			TLS_CONFIGS: &models.TLSConfig{
+				TLS_ENABLED:              tlsEnabledCheck.Checked,
+				TLS_CA_FILE:              tlsCAField.Text,
+				TLS_CERT_FILE:            tlsCertField.Text,
+				TLS_KEY_FILE:             tlsKeyField.Text,
+				TLS_SERVER_NAME:          tlsServerNameField.Text,
+				TLS_MIN_VERSION:          tlsMinVersionSelect.Selected,
+				TLS_INSECURE_SKIP_VERIFY: tlsSkipVerifyCheck.Checked,
 			},
 		}
 
-		if kafkaConfig.KAFKA_SASL_MECHANISM == "SASL/PLAIN" {
-			kafkaConfig.KAFKA_SASL_MECHANISM = "PLAIN"
-		}
+		kafkaConfig.KAFKA_SASL_MECHANISM = utils.NormalizeSASLMechanism(kafkaConfig.KAFKA_SASL_MECHANISM)
 
 		creationChan <- kafkaConfig
 
@@ -242,6 +595,7 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 		kafkaTopicField.SetText("")
 		kafkaConsumerIdField.SetText("")
 		kafkaConsumerOffsetField.SetText("")
+		kafkaPartitionsField.SetText("")
 		saslMechanism.SetSelected("")
 		kafkaSASLUserField.SetText("")
 		kafkaSASLPasswordField.SetText("")
@@ -249,13 +603,40 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 		azureTenantIdField.SetText("")
 		azureApplicationIdField.SetText("")
 		azureApplicationSecretField.SetText("")
-		dataFormatRadio.SetSelected("")
+		azureCertificateField.SetText("")
+		azureCertificatePasswordField.SetText("")
+		azureManagedIdentityClientIdField.SetText("")
+		azureFederatedTokenFileField.SetText("")
+		azureCredentialTypeSelect.SetSelected(datastore.AzureClientSecret)
+		azureCloudSelect.SetSelected(datastore.AzureCloudPublic)
+		krb5ConfigField.SetText("")
+		krb5RealmField.SetText("")
+		krb5ServiceNameField.SetText("")
+		krb5PrincipalField.SetText("")
+		krb5AuthTypeRadio.SetSelected("")
+		krb5KeytabField.SetText("")
+		krb5CCacheField.SetText("")
+		krb5DisableFastCheck.SetChecked(false)
+		tlsEnabledCheck.SetChecked(false)
+		tlsCAField.SetText("")
+		tlsCertField.SetText("")
+		tlsKeyField.SetText("")
+		tlsServerNameField.SetText("")
+		tlsMinVersionSelect.ClearSelected()
+		tlsSkipVerifyCheck.SetChecked(false)
+		dataFormatSelect.SetSelected(datastore.DecoderAuto)
+		compressionSelect.SetSelected(datastore.CompressionAuto)
 		avroSchemaUrlField.SetText("")
 		avroSchemaVersionField.SetText("")
+		avroSchemaSubjectField.SetText("")
+		avroRegistryUserField.SetText("")
+		avroRegistryPasswordField.SetText("")
+		avroRegistryTokenField.SetText("")
+		avroSchemaFileField.SetText("")
+		protobufDescriptorSetField.SetText("")
+		protobufMessageTypeField.SetText("")
 	})
 
-	dataFormatRadio.Horizontal = true
-
 	loadJson := widget.NewButton("Import From JSON", func() {
 		fileDialog := dialog.NewFileOpen(
 			func(r fyne.URIReadCloser, err error) {
@@ -272,10 +653,14 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 					}
 
 					for i := range configs {
-						if configs[i].KAFKA_SASL_MECHANISM == "SASL/PLAIN" {
-							configs[i].KAFKA_SASL_MECHANISM = "PLAIN"
+						err := utils.ApplyConnectionString(&configs[i])
+						if err != nil {
+							dialog.ShowError(fmt.Errorf("error in config %d: %v", i+1, err), g.Window)
+							continue
 						}
 
+						configs[i].KAFKA_SASL_MECHANISM = utils.NormalizeSASLMechanism(configs[i].KAFKA_SASL_MECHANISM)
+
 						creationChan <- configs[i]
 					}
 
@@ -290,6 +675,23 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 
 	})
 
+	pasteConnectionString := widget.NewButtonWithIcon("Paste Connection String", theme.ContentPasteIcon(), func() {
+		connStr, err := utils.ParseEventHubConnectionString(g.Window.Clipboard().Content())
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+			return
+		}
+
+		kafkaHostField.SetText(connStr.Host())
+		if connStr.EntityPath != "" {
+			kafkaTopicField.SetText(connStr.EntityPath)
+		}
+
+		saslMechanism.SetSelected("SASL/PLAIN")
+		kafkaSASLUserField.SetText("$ConnectionString")
+		kafkaSASLPasswordField.SetText(connStr.Raw)
+	})
+
 	kafkaConfigFields := []*widget.FormItem{
 		{
 			Text:   "KAFKA HOST",
@@ -307,6 +709,10 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 			Text:   "KAFKA CONSUMER OFFSET",
 			Widget: kafkaConsumerOffsetField,
 		},
+		{
+			Text:   "KAFKA PARTITIONS",
+			Widget: kafkaPartitionsField,
+		},
 		{
 			Text:     "KAFKA SASL MECHANISM",
 			HintText: "Select Your Preferred Mechanism (Mandatory Field)",
@@ -324,6 +730,14 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 			Text:   "AZURE AUDIENCE",
 			Widget: azureAudienceField,
 		},
+		{
+			Text:   "AZURE CLOUD",
+			Widget: azureCloudSelect,
+		},
+		{
+			Text:   "AZURE CREDENTIAL TYPE",
+			Widget: azureCredentialTypeSelect,
+		},
 		{
 			Text:   "AZURE TENANT ID",
 			Widget: azureTenantIdField,
@@ -336,19 +750,129 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 			Text:   "AZURE APPLICATION SECRET",
 			Widget: azureApplicationSecretField,
 		},
+		{
+			Text:   "AZURE CERTIFICATE",
+			Widget: utils.CreateFileEntryWidget(azureCertificateField, g.Window),
+		},
+		{
+			Text:   "AZURE CERTIFICATE PASSWORD",
+			Widget: azureCertificatePasswordField,
+		},
+		{
+			Text:   "AZURE MANAGED IDENTITY ID",
+			Widget: azureManagedIdentityClientIdField,
+		},
+		{
+			Text:   "AZURE FEDERATED TOKEN FILE",
+			Widget: utils.CreateFileEntryWidget(azureFederatedTokenFileField, g.Window),
+		},
+		{
+			Text:   "KERBEROS KRB5.CONF",
+			Widget: utils.CreateFileEntryWidget(krb5ConfigField, g.Window),
+		},
+		{
+			Text:   "KERBEROS PRINCIPAL",
+			Widget: krb5PrincipalField,
+		},
+		{
+			Text:   "KERBEROS REALM",
+			Widget: krb5RealmField,
+		},
+		{
+			Text:   "KERBEROS SERVICE NAME",
+			Widget: krb5ServiceNameField,
+		},
+		{
+			Text:   "KERBEROS AUTH TYPE",
+			Widget: krb5AuthTypeRadio,
+		},
+		{
+			Text:   "KERBEROS KEYTAB",
+			Widget: utils.CreateFileEntryWidget(krb5KeytabField, g.Window),
+		},
+		{
+			Text:   "KERBEROS CREDENTIAL CACHE",
+			Widget: utils.CreateFileEntryWidget(krb5CCacheField, g.Window),
+		},
+		{
+			Text:   "KERBEROS PA-FX-FAST",
+			Widget: krb5DisableFastCheck,
+		},
+		{
+			Text:     "KAFKA TLS",
+			HintText: "Defaults To The SASL Mechanism, PLAINTEXT Has No TLS",
+			Widget:   tlsEnabledCheck,
+		},
+		{
+			Text:   "TLS CA FILE",
+			Widget: utils.CreateFileEntryWidget(tlsCAField, g.Window),
+		},
+		{
+			Text:   "TLS CLIENT CERT FILE",
+			Widget: utils.CreateFileEntryWidget(tlsCertField, g.Window),
+		},
+		{
+			Text:   "TLS CLIENT KEY FILE",
+			Widget: utils.CreateFileEntryWidget(tlsKeyField, g.Window),
+		},
+		{
+			Text:   "TLS SERVER NAME",
+			Widget: tlsServerNameField,
+		},
+		{
+			Text:   "TLS MIN VERSION",
+			Widget: tlsMinVersionSelect,
+		},
+		{
+			Text:   "TLS SKIP VERIFY",
+			Widget: tlsSkipVerifyCheck,
+		},
 		{
 			Text:     "DATA FORMAT TYPE",
-			HintText: "Select Receiving Data Format (Mandatory Field)",
-			Widget:   dataFormatRadio,
+			HintText: "Select Receiving Data Format, It Can Be Changed Per Topic Later",
+			Widget:   dataFormatSelect,
+		},
+		{
+			Text:     "PAYLOAD COMPRESSION",
+			HintText: "Applied By The Producer Before Decoding, AUTO Detects It",
+			Widget:   compressionSelect,
 		},
 		{
-			Text:   "AVRO SCHEMA URL",
+			Text:   "SCHEMA REGISTRY URL",
 			Widget: avroSchemaUrlField,
 		},
+		{
+			Text:   "AVRO SCHEMA SUBJECT",
+			Widget: avroSchemaSubjectField,
+		},
 		{
 			Text:   "AVRO SCHEMA VERSION",
 			Widget: avroSchemaVersionField,
 		},
+		{
+			Text:   "SCHEMA REGISTRY USERNAME",
+			Widget: avroRegistryUserField,
+		},
+		{
+			Text:   "SCHEMA REGISTRY PASSWORD",
+			Widget: avroRegistryPasswordField,
+		},
+		{
+			Text:   "SCHEMA REGISTRY TOKEN",
+			Widget: avroRegistryTokenField,
+		},
+		{
+			Text:   "AVRO SCHEMA FILE",
+			Widget: utils.CreateFileEntryWidget(avroSchemaFileField, g.Window),
+		},
+		{
+			Text:   "PROTOBUF DESCRIPTOR SET",
+			Widget: utils.CreateFileEntryWidget(protobufDescriptorSetField, g.Window),
+		},
+		{
+			Text:   "PROTOBUF MESSAGE TYPE",
+			Widget: protobufMessageTypeField,
+		},
 		{
 			Text:   "",
 			Widget: submitButton,
@@ -357,6 +881,10 @@ func (g *GUI) CreateKafkaConfigForm(creationChan chan models.Config) *fyne.Conta
 			Text:   "",
 			Widget: loadJson,
 		},
+		{
+			Text:   "",
+			Widget: pasteConnectionString,
+		},
 	}
 	form.Items = append(form.Items, kafkaConfigFields...)
 
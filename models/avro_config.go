@@ -3,4 +3,15 @@ package models
 type AvroConfig struct {
 	SCHEMA_URL     string `json:"AVRO_SCHEMA_URL,omitempty"`
 	SCHEMA_VERSION string `json:"AVRO_SCHEMA_VERSION,omitempty"`
+	SCHEMA_FILE    string `json:"AVRO_SCHEMA_FILE,omitempty"`    // Local .avsc file used by AVRO_LOCAL for unframed payloads
+	SCHEMA_SUBJECT string `json:"AVRO_SCHEMA_SUBJECT,omitempty"` // Registry subject used by AVRO_SUBJECT with SCHEMA_VERSION, latest when no version is set
+
+	// Schema registry authentication, either basic auth or a bearer token
+	SCHEMA_REGISTRY_USER  string `json:"AVRO_SCHEMA_REGISTRY_USERNAME,omitempty"`
+	SCHEMA_REGISTRY_PASS  string `json:"AVRO_SCHEMA_REGISTRY_PASSWORD,omitempty"`
+	SCHEMA_REGISTRY_TOKEN string `json:"AVRO_SCHEMA_REGISTRY_TOKEN,omitempty"`
+
+	SCHEMA_REGISTRY_TIMEOUT int    `json:"AVRO_SCHEMA_REGISTRY_TIMEOUT,omitempty"` // Seconds per request, defaults to 10
+	SCHEMA_REGISTRY_RETRIES int    `json:"AVRO_SCHEMA_REGISTRY_RETRIES,omitempty"` // Defaults to 3
+	SCHEMA_CACHE_DIR        string `json:"AVRO_SCHEMA_CACHE_DIR,omitempty"`        // Defaults to the user cache directory, "-" disables the disk cache
 }
@@ -5,4 +5,12 @@ type AzureConfig struct {
 	AAD_APPLICATION_ID     string `json:"AAD_APPLICATION_ID,omitempty"`
 	AAD_APPLICATION_SECRET string `json:"AAD_APPLICATION_SECRET,omitempty"`
 	AAD_TENANT_ID          string `json:"AAD_TENANT_ID,omitempty"`
+
+	// CLIENT_SECRET (default), CLIENT_CERTIFICATE, AZURE_CLI, MANAGED_IDENTITY, WORKLOAD_IDENTITY or DEFAULT
+	AAD_CREDENTIAL_TYPE            string `json:"AAD_CREDENTIAL_TYPE,omitempty"`
+	AAD_CERTIFICATE_PATH           string `json:"AAD_CERTIFICATE_PATH,omitempty"`           // PEM or PKCS#12 file with the certificate and private key
+	AAD_CERTIFICATE_PASSWORD       string `json:"AAD_CERTIFICATE_PASSWORD,omitempty"`       // Only for encrypted PKCS#12 files
+	AAD_MANAGED_IDENTITY_CLIENT_ID string `json:"AAD_MANAGED_IDENTITY_CLIENT_ID,omitempty"` // User assigned identity, empty for the system assigned one
+	AAD_FEDERATED_TOKEN_FILE       string `json:"AAD_FEDERATED_TOKEN_FILE,omitempty"`       // Defaults to AZURE_FEDERATED_TOKEN_FILE
+	AAD_CLOUD                      string `json:"AAD_CLOUD,omitempty"`                      // PUBLIC (default), US_GOVERNMENT or CHINA
 }
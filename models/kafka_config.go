@@ -1,13 +1,23 @@
 package models
 
 type Config struct {
-	KAFKA_HOSTS             string       `json:"KAFKA_HOSTS"`
-	KAFKA_TOPIC             string       `json:"KAFKA_TOPIC"`
-	KAFKA_CONSUMER_GROUP_ID string       `json:"KAFKA_CONSUMER_GROUP_ID"`
-	KAFKA_CONSUMER_OFFSET   string       `json:"KAFKA_CONSUMER_OFFSET"`
-	KAFKA_SASL_USER         string       `json:"KAFKA_SASL_USERNAME"`
-	KAFKA_SASL_PASS         string       `json:"KAFKA_SASL_PASSWORD"`
-	KAFKA_SASL_MECHANISM    string       `json:"KAFKA_SASL_MECHANISM"`
-	AZURE_CONFIGS           *AzureConfig `json:"AZURE_CONFIGS,omitempty"`
-	AVRO_CONFIGS            *AvroConfig  `json:"AVRO_CONFIGS,omitempty"`
+	KAFKA_HOSTS             string          `json:"KAFKA_HOSTS"`
+	KAFKA_TOPIC             string          `json:"KAFKA_TOPIC"`
+	KAFKA_CONSUMER_GROUP_ID string          `json:"KAFKA_CONSUMER_GROUP_ID"`
+	KAFKA_CONSUMER_OFFSET   string          `json:"KAFKA_CONSUMER_OFFSET"`
+	KAFKA_PARTITIONS        string          `json:"KAFKA_PARTITIONS,omitempty"`
+	KAFKA_SASL_USER         string          `json:"KAFKA_SASL_USERNAME"`
+	KAFKA_SASL_PASS         string          `json:"KAFKA_SASL_PASSWORD"`
+	KAFKA_SASL_MECHANISM    string          `json:"KAFKA_SASL_MECHANISM"`
+	AZURE_CONFIGS           *AzureConfig    `json:"AZURE_CONFIGS,omitempty"`
+	AVRO_CONFIGS            *AvroConfig     `json:"AVRO_CONFIGS,omitempty"`
+	TLS_CONFIGS             *TLSConfig      `json:"TLS_CONFIGS,omitempty"` // Without it TLS is enabled for every SASL mechanism but PLAINTEXT
+	KERBEROS_CONFIGS        *KerberosConfig `json:"KERBEROS_CONFIGS,omitempty"`
+	CONNECTION_STRING       string          `json:"CONNECTION_STRING,omitempty"` // Event Hubs connection string, fills the hosts, topic and SASL/PLAIN credentials
+	DATA_FORMAT             string          `json:"DATA_FORMAT,omitempty"`       // AUTO (default, tries JSON then AVRO first like before), JSON, AVRO, AVRO_LOCAL, AVRO_SUBJECT, PROTOBUF, MSGPACK, CBOR, TEXT or HEX
+	PROTOBUF_CONFIGS        *ProtobufConfig `json:"PROTOBUF_CONFIGS,omitempty"`
+
+	TOPIC_DATA_FORMATS map[string]string `json:"TOPIC_DATA_FORMATS,omitempty"` // Overrides DATA_FORMAT per topic
+	COMPRESSION        string            `json:"COMPRESSION,omitempty"`        // AUTO (default, detected by magic bytes), NONE, GZIP, ZSTD, SNAPPY or LZ4
+	TOPIC_COMPRESSIONS map[string]string `json:"TOPIC_COMPRESSIONS,omitempty"` // Overrides COMPRESSION per topic
 }
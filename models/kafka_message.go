@@ -1,10 +1,16 @@
 package models
 
+import "time"
+
 type Message struct {
-	Headers   map[string]string
-	Offset    int64
-	Topic     string
-	Partition int32
-	Value     []byte
-	Logs      string
+	Headers        map[string]string
+	Key            []byte
+	Offset         int64
+	Topic          string
+	Partition      int32
+	Value          []byte
+	Timestamp      time.Time // Producer or log append time, depending on the topic configuration
+	BlockTimestamp time.Time // Timestamp of the record batch, only set for v2 record batches
+	Logs           string
+	DecodeError    string // Why the payload couldn't be decoded, Value then holds the raw bytes
 }
@@ -0,0 +1,12 @@
+package models
+
+type KerberosConfig struct {
+	KRB5_CONFIG_PATH        string `json:"KRB5_CONFIG_PATH,omitempty"`
+	KRB5_REALM              string `json:"KRB5_REALM,omitempty"`        // Taken from the principal when empty
+	KRB5_SERVICE_NAME       string `json:"KRB5_SERVICE_NAME,omitempty"` // Defaults to kafka
+	KRB5_PRINCIPAL          string `json:"KRB5_PRINCIPAL,omitempty"`    // user or user@REALM
+	KRB5_AUTH_TYPE          string `json:"KRB5_AUTH_TYPE,omitempty"`    // KEYTAB or CCACHE
+	KRB5_KEYTAB_PATH        string `json:"KRB5_KEYTAB_PATH,omitempty"`
+	KRB5_CCACHE_PATH        string `json:"KRB5_CCACHE_PATH,omitempty"`
+	KRB5_DISABLE_PA_FX_FAST bool   `json:"KRB5_DISABLE_PA_FX_FAST,omitempty"` // Needed by Active Directory KDCs that don't support FAST
+}
@@ -0,0 +1,8 @@
+package models
+
+// ProtobufConfig is only needed to decode with a local descriptor set, schemas
+// are otherwise fetched from the schema registry set in AVRO_CONFIGS.
+type ProtobufConfig struct {
+	DESCRIPTOR_SET_PATH string `json:"PROTOBUF_DESCRIPTOR_SET,omitempty"` // protoc --include_imports --descriptor_set_out output
+	MESSAGE_TYPE        string `json:"PROTOBUF_MESSAGE_TYPE,omitempty"`   // Fully qualified name eg: orders.v1.Order
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Record is a message stored in the records table.
+type Record struct {
+	ID        int64
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Headers   map[string]string
+	Message   string
+	Timestamp time.Time
+
+	DecodeError string // Set for messages that couldn't be decoded, Raw then holds the payload
+	Raw         []byte
+}
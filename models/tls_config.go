@@ -0,0 +1,11 @@
+package models
+
+type TLSConfig struct {
+	TLS_ENABLED              bool   `json:"TLS_ENABLED"`
+	TLS_CA_FILE              string `json:"TLS_CA_FILE,omitempty"`     // PEM bundle added to the system roots
+	TLS_CERT_FILE            string `json:"TLS_CERT_FILE,omitempty"`   // Client certificate for mTLS
+	TLS_KEY_FILE             string `json:"TLS_KEY_FILE,omitempty"`    // Client key for mTLS
+	TLS_SERVER_NAME          string `json:"TLS_SERVER_NAME,omitempty"` // Overrides the host name checked against the certificate
+	TLS_MIN_VERSION          string `json:"TLS_MIN_VERSION,omitempty"` // 1.0, 1.1, 1.2 or 1.3
+	TLS_INSECURE_SKIP_VERIFY bool   `json:"TLS_INSECURE_SKIP_VERIFY,omitempty"`
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	datastore "github.com/krogertechnology/data-tracker/datastore/kafka"
+	"github.com/krogertechnology/data-tracker/service"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+// CreateProduceView builds the tab used to publish test messages with the client of the connection.
+func (g *GUI) CreateProduceView(k *service.KafkaOBJ) fyne.CanvasObject {
+	topicSelect := widget.NewSelect(k.Configs.TOPICS, nil)
+	topicSelect.SetSelected(k.Configs.TOPICS[0])
+
+	keyField := utils.CreateEntryWidget("Key", false, false)
+
+	// Checked by default so the messages are spread like the ones of producers without keys
+	nullKeyCheck := widget.NewCheck("Null key", func(nullKey bool) {
+		utils.SetEnabled(!nullKey, keyField)
+	})
+	nullKeyCheck.SetChecked(true)
+
+	headersField := widget.NewMultiLineEntry()
+	headersField.SetPlaceHolder("Headers, one key=value per line")
+	headersField.SetMinRowsVisible(3)
+
+	partitionField := utils.CreateEntryWidget("Partition", false, false)
+
+	partitionerSelect := widget.NewSelect(datastore.Partitioners, func(partitioner string) {
+		utils.SetEnabled(partitioner == datastore.PartitionerManual, partitionField)
+	})
+	partitionerSelect.SetSelected(datastore.PartitionerHash)
+
+	subjectField := utils.CreateEntryWidget("Subject, <topic>-value when empty", false, false)
+	versionField := utils.CreateEntryWidget("Version, latest when empty", false, false)
+
+	encodingSelect := widget.NewSelect(datastore.Encodings, func(encoding string) {
+		utils.SetEnabled(encoding == datastore.EncodingAvro, subjectField, versionField)
+	})
+	encodingSelect.SetSelected(datastore.EncodingJSON)
+
+	bodyField := widget.NewMultiLineEntry()
+	bodyField.SetPlaceHolder("JSON body, in the Avro JSON encoding for AVRO eg: {\"name\": {\"string\": \"x\"}}\nA tombstone is sent when empty")
+	bodyField.SetMinRowsVisible(12)
+
+	resultLabel := widget.NewLabel("")
+
+	var sendButton *widget.Button
+	sendButton = widget.NewButtonWithIcon("Send", theme.MailSendIcon(), func() {
+		req, err := parseProduceRequest(topicSelect.Selected, keyField.Text, nullKeyCheck.Checked, headersField.Text, partitionerSelect.Selected, partitionField.Text)
+		if err != nil {
+			dialog.ShowError(err, g.Window)
+			return
+		}
+
+		req.Body = []byte(bodyField.Text)
+		req.Encoding = encodingSelect.Selected
+
+		if req.Encoding == datastore.EncodingAvro {
+			req.AvroSubject = strings.TrimSpace(subjectField.Text)
+			req.AvroVersion = strings.TrimSpace(versionField.Text)
+		}
+
+		sendButton.Disable()
+		resultLabel.SetText("Sending...")
+
+		// Fetching the schema and waiting for the acks must not block the UI
+		go func() {
+			defer sendButton.Enable()
+
+			partition, offset, err := k.Produce(req)
+			if err != nil {
+				resultLabel.SetText("")
+				dialog.ShowError(err, g.Window)
+				return
+			}
+
+			resultLabel.SetText(fmt.Sprintf("Sent to topic %s, Partition %d with Offset %d", req.Topic, partition, offset))
+		}()
+	})
+
+	form := container.NewVBox(
+		container.NewGridWithColumns(2, topicSelect, container.NewBorder(nil, nil, nil, nullKeyCheck, keyField)),
+		headersField,
+		container.NewGridWithColumns(2, partitionerSelect, partitionField),
+		container.NewGridWithColumns(3, encodingSelect, subjectField, versionField),
+	)
+
+	buttons := container.NewHBox(sendButton, resultLabel, layout.NewSpacer())
+
+	return container.NewBorder(form, buttons, nil, nil, bodyField)
+}
+
+// parseProduceRequest reads the form, an empty key is sent as an empty string unless nullKey is set.
+func parseProduceRequest(topic, key string, nullKey bool, headers, partitioner, partition string) (datastore.ProduceRequest, error) {
+	req := datastore.ProduceRequest{Topic: topic, Partitioner: partitioner}
+
+	if !nullKey {
+		req.Key = []byte(key)
+	}
+
+	req.Headers = make(map[string]string, 0)
+	for _, line := range strings.Split(headers, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return req, fmt.Errorf("invalid header %q, expected key=value", line)
+		}
+
+		req.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	if partitioner == datastore.PartitionerManual {
+		p, err := strconv.ParseInt(strings.TrimSpace(partition), 10, 32)
+		if err != nil || p < 0 {
+			return req, fmt.Errorf("invalid partition: %v", partition)
+		}
+
+		req.Partition = int32(p)
+	}
+
+	return req, nil
+}
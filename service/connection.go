@@ -1,10 +1,18 @@
 package service
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
-	"fyne.io/fyne/v2/widget"
 	"github.com/IBM/sarama"
 
 	datastore "github.com/krogertechnology/data-tracker/datastore/kafka"
@@ -13,46 +21,128 @@ import (
 )
 
 type KafkaOBJ struct {
-	Configs       datastore.KafkaConfig
-	DataChannel   map[string]chan models.Message
-	ClearMsgAfter int
+	Configs     datastore.KafkaConfig
+	DataChannel map[string]chan models.Message
+
+	mu            sync.Mutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	client        sarama.Client
+	consumer      sarama.Consumer
+	consumerGroup sarama.ConsumerGroup
+	producer      sarama.SyncProducer // Created by the first Produce
+	reading       bool
+	readDone      chan struct{}
+	paused        bool
+
+	statsMu sync.Mutex
+	stats   map[string]*DecodeStats
+
+	decodersMu   sync.Mutex
+	decoders     map[string]datastore.Decoder // Per topic
+	formats      map[string]string
+	compressions map[string]string
 }
 
-func NewKafkaObj(k models.Config, clearMsgAfter int) *KafkaOBJ {
+// DecodeStats counts the messages of a topic by decoding outcome.
+type DecodeStats struct {
+	Decoded   int
+	Failed    int
+	LastError string
+}
+
+// maxDumpBytes limits the raw payload shown for a message that couldn't be decoded
+const maxDumpBytes = 512
+
+func NewKafkaObj(k models.Config) *KafkaOBJ {
 	topics := utils.GetElementsFromString(k.KAFKA_TOPIC)
 
+	// Protobuf schemas come from the same registry as the Avro ones
+	avroConfig := datastore.NewAvroConfig(k.AVRO_CONFIGS)
+
+	var registry *datastore.SchemaRegistry
+	if avroConfig != nil {
+		registry = avroConfig.Registry
+	}
+
 	config := datastore.KafkaConfig{
 		KAFKA_HOSTS:             k.KAFKA_HOSTS,
 		KAFKA_TOPIC:             k.KAFKA_TOPIC,
 		KAFKA_CONSUMER_GROUP_ID: k.KAFKA_CONSUMER_GROUP_ID,
 		KAFKA_CONSUMER_OFFSET:   k.KAFKA_CONSUMER_OFFSET,
+		KAFKA_PARTITIONS:        k.KAFKA_PARTITIONS,
 		TOPICS:                  topics,
 		KAFKA_SASL_USER:         k.KAFKA_SASL_USER,
 		KAFKA_SASL_PASS:         k.KAFKA_SASL_PASS,
 		KAFKA_SASL_MECHANISM:    k.KAFKA_SASL_MECHANISM,
+		DATA_FORMAT:             k.DATA_FORMAT,
+		TOPIC_DATA_FORMATS:      k.TOPIC_DATA_FORMATS,
+		COMPRESSION:             k.COMPRESSION,
+		TOPIC_COMPRESSIONS:      k.TOPIC_COMPRESSIONS,
 		AzureConfig:             k.AZURE_CONFIGS,
-		AvroConfig:              (*datastore.AvroConfig)(k.AVRO_CONFIGS),
+		AvroConfig:              avroConfig,
+		ProtobufConfig:          datastore.NewProtobufConfig(k.PROTOBUF_CONFIGS, registry),
+		TLSConfig:               k.TLS_CONFIGS,
+		KerberosConfig:          k.KERBEROS_CONFIGS,
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &KafkaOBJ{
-		Configs:       config,
-		ClearMsgAfter: clearMsgAfter,
-		DataChannel:   make(map[string]chan models.Message),
+		Configs:      config,
+		DataChannel:  make(map[string]chan models.Message),
+		ctx:          ctx,
+		cancel:       cancel,
+		readDone:     make(chan struct{}),
+		stats:        make(map[string]*DecodeStats, 0),
+		decoders:     make(map[string]datastore.Decoder, 0),
+		formats:      make(map[string]string, 0),
+		compressions: make(map[string]string, 0),
 	}
 }
 
 func (k *KafkaOBJ) SetupEventhub() (sarama.Client, error) {
+	// The decoders are built first so a missing schema is reported before connecting
+	for _, topic := range k.Configs.TOPICS {
+		err := k.SetDataFormat(topic, k.Configs.TopicDataFormat(topic))
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %v", topic, err)
+		}
+
+		err = k.SetCompression(topic, k.Configs.TopicCompression(topic))
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %v", topic, err)
+		}
+	}
+
 	client, err := k.Configs.EstablishKafkaConn()
 	if err != nil {
 		return nil, err
 	}
 
+	k.mu.Lock()
+	k.client = client
+	k.mu.Unlock()
+
 	return client, nil
 }
 
+// Read consumes into the data channels until Disconnect is called.
 func (k *KafkaOBJ) Read(client sarama.Client) error {
+	k.mu.Lock()
+	if k.ctx.Err() != nil {
+		k.mu.Unlock()
+		return nil
+	}
+
+	k.reading = true
+	k.mu.Unlock()
+
+	defer close(k.readDone)
+
 	kafkaConsumer := datastore.KafkaConsumer{
-		Configs: &k.Configs,
+		Configs:    &k.Configs,
+		WhenPaused: k.whenPaused,
 	}
 
 	if len(k.Configs.TOPICS) == 1 {
@@ -63,9 +153,17 @@ func (k *KafkaOBJ) Read(client sarama.Client) error {
 
 		defer consumer.Close()
 
+		// Pause may have been clicked before the consumer existed
+		k.mu.Lock()
+		k.consumer = consumer
+		if k.paused {
+			consumer.PauseAll()
+		}
+		k.mu.Unlock()
+
 		kafkaConsumer.Consumer = consumer
 
-		err = kafkaConsumer.ReadFromConsumer(k.DataChannel)
+		err = kafkaConsumer.ReadFromConsumer(k.ctx, k.DataChannel)
 		if err != nil {
 			return err
 		}
@@ -78,9 +176,16 @@ func (k *KafkaOBJ) Read(client sarama.Client) error {
 
 		defer consumerGroup.Close()
 
+		k.mu.Lock()
+		k.consumerGroup = consumerGroup
+		if k.paused {
+			consumerGroup.PauseAll()
+		}
+		k.mu.Unlock()
+
 		kafkaConsumer.ConsumerGroup = consumerGroup
 
-		err = kafkaConsumer.ReadFromConsumerGroup(k.DataChannel)
+		err = kafkaConsumer.ReadFromConsumerGroup(k.ctx, k.DataChannel)
 		if err != nil {
 			return err
 		}
@@ -89,77 +194,366 @@ func (k *KafkaOBJ) Read(client sarama.Client) error {
 	return nil
 }
 
-func (k *KafkaOBJ) Listen(widgetMap map[string]*widget.Label) error {
-	var wg sync.WaitGroup
+// SetDataFormat switches the decoder of the topic, it applies to the next message.
+func (k *KafkaOBJ) SetDataFormat(topic, format string) error {
+	decoder, err := k.Configs.NewDecoder(format)
+	if err != nil {
+		return err
+	}
 
-	for topic := range k.DataChannel {
-		wg.Add(1)
+	k.decodersMu.Lock()
+	defer k.decodersMu.Unlock()
 
-		go func(topic string) error {
-			defer wg.Done()
+	k.decoders[topic] = decoder
+	k.formats[topic] = format
 
-			err := k.ConsumeAndOverwriteText(topic, widgetMap[topic])
-			if err != nil {
-				return fmt.Errorf("error displaying data for topic %s: %v", topic, err)
-			}
+	return nil
+}
 
-			return nil
+func (k *KafkaOBJ) DataFormat(topic string) string {
+	k.decodersMu.Lock()
+	defer k.decodersMu.Unlock()
 
-		}(topic)
+	return k.formats[topic]
+}
+
+// SetCompression selects how the payloads of the topic are decompressed before decoding.
+func (k *KafkaOBJ) SetCompression(topic, compression string) error {
+	err := datastore.ValidateCompression(compression)
+	if err != nil {
+		return err
 	}
 
-	wg.Wait()
+	k.decodersMu.Lock()
+	defer k.decodersMu.Unlock()
+
+	k.compressions[topic] = compression
 
 	return nil
 }
 
-func (k *KafkaOBJ) ConsumeAndOverwriteText(topic string, textWidget *widget.Label) error {
-	channel := k.DataChannel[topic]
-	count := 1
+func (k *KafkaOBJ) Compression(topic string) string {
+	k.decodersMu.Lock()
+	defer k.decodersMu.Unlock()
+
+	return k.compressions[topic]
+}
+
+func (k *KafkaOBJ) decoder(topic string) datastore.Decoder {
+	k.decodersMu.Lock()
+	defer k.decodersMu.Unlock()
+
+	return k.decoders[topic]
+}
+
+// Pause stops fetching from the brokers, the messages already fetched are still delivered.
+func (k *KafkaOBJ) Pause() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
 
-	for message := range channel {
-		var (
-			data = message.Value
-			err  error
-			info string
-		)
+	if k.consumer != nil {
+		k.consumer.PauseAll()
+	}
+
+	if k.consumerGroup != nil {
+		k.consumerGroup.PauseAll()
+	}
+
+	k.paused = true
+}
+
+func (k *KafkaOBJ) Resume() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.consumer != nil {
+		k.consumer.ResumeAll()
+	}
+
+	if k.consumerGroup != nil {
+		k.consumerGroup.ResumeAll()
+	}
+
+	k.paused = false
+}
+
+// whenPaused pauses a partition consumer started after Pause, under the lock so it can't race with Resume.
+func (k *KafkaOBJ) whenPaused(pause func()) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.paused {
+		pause()
+	}
+}
+
+func (k *KafkaOBJ) Paused() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.paused
+}
+
+// Produce publishes a message through the client of the connection and returns where it was written.
+func (k *KafkaOBJ) Produce(req datastore.ProduceRequest) (int32, int64, error) {
+	msg, err := k.Configs.NewProducerMessage(req)
+	if err != nil {
+		return -1, -1, err
+	}
 
-		// Clear Existing Messages
-		if count > k.ClearMsgAfter {
-			textWidget.SetText("")
+	producer, err := k.syncProducer()
+	if err != nil {
+		return -1, -1, err
+	}
+
+	partition, offset, err := producer.SendMessage(msg)
+	if err != nil {
+		return -1, -1, fmt.Errorf("error producing to topic %s: %v", req.Topic, err)
+	}
+
+	return partition, offset, nil
+}
+
+func (k *KafkaOBJ) syncProducer() (sarama.SyncProducer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.ctx.Err() != nil || k.client == nil {
+		return nil, errors.New("the connection is closed")
+	}
+
+	if k.producer == nil {
+		producer, err := datastore.CreateProducer(k.client)
+		if err != nil {
+			return nil, err
 		}
 
-		if data == nil {
-			// LOG
-			info = textWidget.Text + message.Logs
-			textWidget.SetText(info)
+		k.producer = producer
+	}
+
+	return k.producer, nil
+}
+
+// Disconnected reports whether Disconnect was called.
+func (k *KafkaOBJ) Disconnected() bool {
+	return k.ctx.Err() != nil
+}
+
+// Disconnect stops the consumers, closes the data channels so Listen returns and closes the client.
+func (k *KafkaOBJ) Disconnect() error {
+	k.mu.Lock()
+	if k.ctx.Err() != nil {
+		k.mu.Unlock()
+		return nil
+	}
+
+	k.cancel()
+	reading := k.reading
+	client := k.client
+	producer := k.producer
+	k.mu.Unlock()
+
+	// The producer has to be closed before the client it was built from
+	if producer != nil {
+		producer.Close()
+	}
+
+	// Nothing is sent on the data channels once Read has returned
+	if reading {
+		<-k.readDone
+	}
+
+	for topic := range k.DataChannel {
+		close(k.DataChannel[topic])
+	}
+
+	if client == nil || client.Closed() {
+		return nil
+	}
+
+	return client.Close()
+}
+
+// Listen delivers the messages of every topic to its sink until the data channels are closed.
+func (k *KafkaOBJ) Listen(sinks map[string]MessageSink) {
+	var wg sync.WaitGroup
+
+	for topic := range k.DataChannel {
+		wg.Add(1)
+
+		go func(topic string) {
+			defer wg.Done()
+
+			k.Consume(topic, sinks[topic])
+		}(topic)
+	}
+
+	wg.Wait()
+}
+
+// Consume decodes the messages of the topic into the sink until its data channel is closed.
+// A message that can't be decoded is delivered with its reason, the next messages are still consumed.
+func (k *KafkaOBJ) Consume(topic string, sink MessageSink) {
+	disabled := datastore.DisabledDecoders(k.decoder(topic))
+	if len(disabled) > 0 {
+		sink.Log(topic, fmt.Sprintf("AUTO skips the data formats that aren't configured: %s\n", strings.Join(disabled, ", ")))
+	}
+
+	for message := range k.DataChannel[topic] {
+		if message.Value == nil {
+			sink.Log(topic, message.Logs)
 			continue
 		}
 
-		if !utils.IsJSON(message.Value) {
-			val, err := k.Configs.ProcessAvroMessage(message)
-			if err != nil {
-				return err
-			}
+		message = k.DecodeMessage(topic, message)
 
-			val.Logs = message.Logs
-			message = *val
+		err := sink.Message(message, k.DecodeStats(topic))
+		if err != nil {
+			sink.Error(topic, err)
 		}
+	}
+}
 
-		data, err = utils.FormatJSONString(message.Value)
-		if err != nil {
-			return fmt.Errorf("error formatting JSON: %v", err)
+// DecodeMessage decompresses and decodes the payload with the settings of the topic into JSON.
+// A message that can't be decoded keeps its raw payload and the reason in DecodeError,
+// the outcome is counted in the decode stats either way.
+func (k *KafkaOBJ) DecodeMessage(topic string, message models.Message) models.Message {
+	decoded, decodeError := k.decode(topic, message)
+	if decodeError != "" {
+		// The stored raw payload stays the original one whatever step failed
+		message.DecodeError = decodeError
+		decoded = message
+	}
+
+	k.recordDecodeResult(topic, decodeError)
+
+	return decoded
+}
+
+func (k *KafkaOBJ) decode(topic string, message models.Message) (models.Message, string) {
+	var err error
+
+	decompressed := message
+	decompressed.Value, err = datastore.Decompress(message.Value, k.Compression(topic))
+	if err != nil {
+		return message, err.Error()
+	}
+
+	val, err := k.decoder(topic).Decode(decompressed)
+	if err != nil {
+		return message, err.Error()
+	}
+
+	if !json.Valid(val.Value) {
+		return message, "error formatting JSON: the decoded payload is not valid JSON"
+	}
+
+	val.Logs = message.Logs
+
+	return *val, ""
+}
+
+func (k *KafkaOBJ) recordDecodeResult(topic, decodeError string) {
+	k.statsMu.Lock()
+	defer k.statsMu.Unlock()
+
+	stats, ok := k.stats[topic]
+	if !ok {
+		stats = &DecodeStats{}
+		k.stats[topic] = stats
+	}
+
+	if decodeError == "" {
+		stats.Decoded += 1
+	} else {
+		stats.Failed += 1
+		stats.LastError = decodeError
+	}
+}
+
+// DecodeStats returns the decoding outcome of the messages consumed from the topic so far.
+func (k *KafkaOBJ) DecodeStats(topic string) DecodeStats {
+	k.statsMu.Lock()
+	defer k.statsMu.Unlock()
+
+	stats, ok := k.stats[topic]
+	if !ok {
+		return DecodeStats{}
+	}
+
+	return *stats
+}
+
+// formatDecodeError renders the error banner and a dump of the raw payload.
+func formatDecodeError(message models.Message, stats DecodeStats) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("!!! DECODE ERROR: %s\n", message.DecodeError))
+	sb.WriteString(fmt.Sprintf("Decode errors on this topic: %d of %d messages\n", stats.Failed, stats.Decoded+stats.Failed))
+
+	raw := message.Value
+	if len(raw) > maxDumpBytes {
+		raw = raw[:maxDumpBytes]
+	}
+
+	sb.WriteString(fmt.Sprintf("Raw payload (%d bytes):\n", len(message.Value)))
+	sb.WriteString(hex.Dump(raw))
+
+	if len(message.Value) > maxDumpBytes {
+		sb.WriteString(fmt.Sprintf("... %d more bytes\n", len(message.Value)-maxDumpBytes))
+	}
+
+	sb.WriteString("Base64: " + base64.StdEncoding.EncodeToString(raw) + "\n")
+
+	return sb.String()
+}
+
+// formatMessageDetails renders the key, timestamps and headers shown under the partition/offset line.
+func formatMessageDetails(message models.Message) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Key: %s | Timestamp: %s", formatKey(message.Key), formatTimestamp(message.Timestamp)))
+	if !message.BlockTimestamp.IsZero() && !message.BlockTimestamp.Equal(message.Timestamp) {
+		sb.WriteString(fmt.Sprintf(" | Block Timestamp: %s", formatTimestamp(message.BlockTimestamp)))
+	}
+
+	sb.WriteString("\n")
+
+	if len(message.Headers) > 0 {
+		keys := make([]string, 0, len(message.Headers))
+		for key := range message.Headers {
+			keys = append(keys, key)
 		}
 
-		info = message.Logs + string(data) + "\n"
-		count += 1
+		sort.Strings(keys)
 
-		if textWidget.Text != "" {
-			textWidget.SetText(textWidget.Text + "\n" + info)
-		} else {
-			textWidget.SetText(info)
+		headers := make([]string, 0, len(keys))
+		for _, key := range keys {
+			headers = append(headers, key+"="+message.Headers[key])
 		}
+
+		sb.WriteString("Headers: " + strings.Join(headers, ", ") + "\n")
 	}
 
-	return nil
+	return sb.String()
+}
+
+func formatKey(key []byte) string {
+	if key == nil {
+		return "<null>"
+	}
+
+	if utf8.Valid(key) {
+		return string(key)
+	}
+
+	return "0x" + hex.EncodeToString(key)
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Local().Format("2006-01-02 15:04:05.000 MST")
 }
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+
+	datastore "github.com/krogertechnology/data-tracker/datastore/kafka"
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// brokenDecoder succeeds without producing JSON
+type brokenDecoder struct{}
+
+func (brokenDecoder) Sniff(value []byte) bool {
+	return false
+}
+
+func (brokenDecoder) Decode(msg models.Message) (*models.Message, error) {
+	msg.Value = []byte("not json")
+	return &msg, nil
+}
+
+func init() {
+	datastore.RegisterDecoder("TEST_BROKEN", func(k *datastore.KafkaConfig) (datastore.Decoder, error) { return brokenDecoder{}, nil })
+}
+
+func gzipped(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(data))
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+func newTestKafkaObj(t *testing.T, format string) *KafkaOBJ {
+	k := NewKafkaObj(models.Config{KAFKA_TOPIC: "orders", DATA_FORMAT: format})
+
+	err := k.SetDataFormat("orders", k.Configs.TopicDataFormat("orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = k.SetCompression("orders", k.Configs.TopicCompression("orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestDecodeMessage(t *testing.T) {
+	compressed := gzipped(t, `{"id":1}`)
+
+	tests := []struct {
+		name          string
+		format        string
+		value         []byte
+		want          string
+		wantDecodeErr string
+	}{
+		{name: "json", format: "JSON", value: []byte(`{"id":1}`), want: `{"id":1}`},
+		{name: "gzipped json", format: "JSON", value: compressed, want: `{"id":1}`},
+		{name: "auto text", format: "", value: []byte("hello"), want: `{"text":"hello"}`},
+		{name: "invalid json", format: "JSON", value: []byte("{oops"), want: "{oops", wantDecodeErr: "not valid JSON"},
+		{name: "compressed invalid json keeps the compressed payload", format: "JSON", value: gzipped(t, "{oops"), want: string(gzipped(t, "{oops")), wantDecodeErr: "not valid JSON"},
+		{name: "decoded payload that isn't JSON", format: "TEST_BROKEN", value: []byte{0x01, 0x02}, want: "\x01\x02", wantDecodeErr: "not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKafkaObj(t, tt.format)
+
+			got := k.DecodeMessage("orders", models.Message{Topic: "orders", Value: tt.value, Logs: "log"})
+
+			if string(got.Value) != tt.want {
+				t.Errorf("Value = %q, want %q", got.Value, tt.want)
+			}
+
+			if !strings.Contains(got.DecodeError, tt.wantDecodeErr) || (tt.wantDecodeErr == "") != (got.DecodeError == "") {
+				t.Errorf("DecodeError = %q, want %q", got.DecodeError, tt.wantDecodeErr)
+			}
+
+			if got.Logs != "log" {
+				t.Errorf("Logs = %q, want them kept", got.Logs)
+			}
+
+			stats := k.DecodeStats("orders")
+			if tt.wantDecodeErr == "" && stats.Decoded != 1 || tt.wantDecodeErr != "" && stats.Failed != 1 {
+				t.Errorf("DecodeStats = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestConsume(t *testing.T) {
+	k := newTestKafkaObj(t, "JSON")
+
+	errSink := errors.New("sink failed")
+	sink := &fakeSink{err: errSink}
+
+	channel := make(chan models.Message, 3)
+	channel <- models.Message{Logs: "Started consuming from partition[0]\n"}
+	channel <- models.Message{Topic: "orders", Offset: 1, Value: []byte(`{"id":1}`)}
+	channel <- models.Message{Topic: "orders", Offset: 2, Value: []byte("{oops")}
+	close(channel)
+
+	k.DataChannel["orders"] = channel
+	k.Consume("orders", sink)
+
+	if len(sink.logs) != 1 || sink.logs[0] != "Started consuming from partition[0]\n" {
+		t.Errorf("logs = %q, want the partition log", sink.logs)
+	}
+
+	if len(sink.messages) != 2 {
+		t.Fatalf("got %d messages, want 2 since a decode error doesn't stop the consumption", len(sink.messages))
+	}
+
+	if sink.messages[0].DecodeError != "" || sink.messages[1].DecodeError == "" {
+		t.Errorf("DecodeError = %q and %q, want only the second one set", sink.messages[0].DecodeError, sink.messages[1].DecodeError)
+	}
+
+	if len(sink.errors) != 2 || !errors.Is(sink.errors[0], errSink) {
+		t.Errorf("errors = %v, want the sink error reported for every message", sink.errors)
+	}
+
+	stats := k.DecodeStats("orders")
+	if stats.Decoded != 1 || stats.Failed != 1 {
+		t.Errorf("DecodeStats = %+v, want 1 decoded and 1 failed", stats)
+	}
+}
+
+func TestConsumeLogsDisabledDecoders(t *testing.T) {
+	k := newTestKafkaObj(t, "")
+	sink := &fakeSink{}
+
+	channel := make(chan models.Message)
+	close(channel)
+
+	k.DataChannel["orders"] = channel
+	k.Consume("orders", sink)
+
+	if len(sink.logs) != 1 || !strings.Contains(sink.logs[0], "AVRO (") {
+		t.Errorf("logs = %q, want the formats AUTO skips", sink.logs)
+	}
+}
@@ -6,21 +6,8 @@ import (
 	"github.com/krogertechnology/data-tracker/datastore/sqlite"
 )
 
-func Migrations() error {
-	db := sqlite.CreateDB()
-	defer db.Close()
-
-	err := db.DeleteExistingData()
-	if err != nil {
-		return err
-	}
-
-	err = db.CreateTable()
-	if err != nil {
-		return err
-	}
-
-	err = db.AddIdx()
+func Migrations(db *sqlite.Store) error {
+	err := db.Migrate()
 	if err != nil {
 		return err
 	}
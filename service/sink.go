@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/krogertechnology/data-tracker/datastore/sqlite"
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+// MessageSink receives what is consumed from a topic. A sink shared between topics
+// is called from one goroutine per topic so it has to be safe for concurrent use.
+type MessageSink interface {
+	// Message receives every consumed message, Value holds the decoded JSON unless DecodeError is set
+	Message(message models.Message, stats DecodeStats) error
+	// Log receives the connection events such as the partitions being consumed
+	Log(topic, text string)
+	// Error receives the errors of the other calls, the next messages are still delivered
+	Error(topic string, err error)
+}
+
+// MultiSink fans the messages out to several sinks.
+type MultiSink []MessageSink
+
+func NewMultiSink(sinks ...MessageSink) MultiSink {
+	return MultiSink(sinks)
+}
+
+// Message delivers the message to every sink even when one of them fails.
+func (m MultiSink) Message(message models.Message, stats DecodeStats) error {
+	errs := make([]error, 0)
+
+	for _, sink := range m {
+		err := sink.Message(message, stats)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m MultiSink) Log(topic, text string) {
+	for _, sink := range m {
+		sink.Log(topic, text)
+	}
+}
+
+func (m MultiSink) Error(topic string, err error) {
+	for _, sink := range m {
+		sink.Error(topic, err)
+	}
+}
+
+// LabelSink displays the messages of a topic in its tab.
+type LabelSink struct {
+	Label         *widget.Label
+	ClearMsgAfter int // The label only keeps the latest message once this many were displayed
+
+	count int
+}
+
+func NewLabelSink(label *widget.Label, clearMsgAfter int) *LabelSink {
+	return &LabelSink{Label: label, ClearMsgAfter: clearMsgAfter, count: 1}
+}
+
+func (l *LabelSink) Message(message models.Message, stats DecodeStats) error {
+	l.clear()
+
+	var info string
+	if message.DecodeError != "" {
+		info = message.Logs + formatMessageDetails(message) + formatDecodeError(message, stats)
+	} else {
+		data, err := utils.FormatJSONString(message.Value)
+		if err != nil {
+			return fmt.Errorf("error formatting offset %d of partition %d: %v", message.Offset, message.Partition, err)
+		}
+
+		info = message.Logs + formatMessageDetails(message) + string(data) + "\n"
+	}
+
+	l.count += 1
+
+	if l.Label.Text != "" {
+		l.Label.SetText(l.Label.Text + "\n" + info)
+	} else {
+		l.Label.SetText(info)
+	}
+
+	return nil
+}
+
+func (l *LabelSink) Log(topic, text string) {
+	l.clear()
+	l.Label.SetText(l.Label.Text + text)
+}
+
+func (l *LabelSink) Error(topic string, err error) {
+	l.Label.SetText(l.Label.Text + fmt.Sprintf("!!! ERROR: %v\n", err))
+}
+
+// clear empties the label before the next message once ClearMsgAfter messages were displayed.
+func (l *LabelSink) clear() {
+	if l.count > l.ClearMsgAfter {
+		l.Label.SetText("")
+	}
+}
+
+// StoreSink persists the messages through the batch writer.
+type StoreSink struct {
+	Recorder *sqlite.BatchWriter
+}
+
+func NewStoreSink(recorder *sqlite.BatchWriter) *StoreSink {
+	return &StoreSink{Recorder: recorder}
+}
+
+func (s *StoreSink) Message(message models.Message, stats DecodeStats) error {
+	return s.Recorder.Write(message)
+}
+
+func (s *StoreSink) Log(topic, text string) {}
+
+func (s *StoreSink) Error(topic string, err error) {}
+
+// WriterRecord is a message as written by a WriterSink.
+type WriterRecord struct {
+	Topic       string            `json:"topic"`
+	Partition   int32             `json:"partition"`
+	Offset      int64             `json:"offset"`
+	Key         *string           `json:"key"` // UTF-8 keys as is, binary keys as 0x hex
+	Timestamp   time.Time         `json:"timestamp"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Value       json.RawMessage   `json:"value,omitempty"`
+	DecodeError string            `json:"decode_error,omitempty"`
+	Raw         []byte            `json:"raw,omitempty"` // Base64 payload of a message that couldn't be decoded
+}
+
+func NewWriterRecord(message models.Message) WriterRecord {
+	record := WriterRecord{
+		Topic:       message.Topic,
+		Partition:   message.Partition,
+		Offset:      message.Offset,
+		Timestamp:   message.Timestamp,
+		Headers:     message.Headers,
+		DecodeError: message.DecodeError,
+	}
+
+	if message.Key != nil {
+		key := formatKey(message.Key)
+		record.Key = &key
+	}
+
+	if message.DecodeError != "" {
+		record.Raw = message.Value
+	} else {
+		record.Value = message.Value
+	}
+
+	return record
+}
+
+// WriterSink writes the messages as indented JSON or as JSON Lines, eg: to stdout or a file.
+type WriterSink struct {
+	Out    io.Writer
+	Logs   io.Writer // Receives the log events and errors, discarded when nil
+	Pretty bool
+
+	mu sync.Mutex
+}
+
+func NewWriterSink(out, logs io.Writer, pretty bool) *WriterSink {
+	return &WriterSink{Out: out, Logs: logs, Pretty: pretty}
+}
+
+func (w *WriterSink) Message(message models.Message, stats DecodeStats) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	record := NewWriterRecord(message)
+
+	if w.Pretty {
+		data, err = json.MarshalIndent(record, "", "  ")
+	} else {
+		data, err = json.Marshal(record)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error formatting offset %d of partition %d: %v", message.Offset, message.Partition, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.Out.Write(append(data, '\n'))
+
+	return err
+}
+
+func (w *WriterSink) Log(topic, text string) {
+	if w.Logs == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fmt.Fprint(w.Logs, text)
+}
+
+func (w *WriterSink) Error(topic string, err error) {
+	w.Log(topic, fmt.Sprintf("error on topic %s: %v\n", topic, err))
+}
+
+// FileSink appends the messages to a file as JSON Lines.
+type FileSink struct {
+	*WriterSink
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{WriterSink: NewWriterSink(file, nil, false), file: file}, nil
+}
+
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
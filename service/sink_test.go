@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// fakeSink records what it receives, Message fails with err when it is set.
+type fakeSink struct {
+	err error
+
+	mu       sync.Mutex
+	messages []models.Message
+	logs     []string
+	errors   []error
+}
+
+func (f *fakeSink) Message(message models.Message, stats DecodeStats) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, message)
+
+	return f.err
+}
+
+func (f *fakeSink) Log(topic, text string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.logs = append(f.logs, text)
+}
+
+func (f *fakeSink) Error(topic string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.errors = append(f.errors, err)
+}
+
+func TestMultiSinkJoinsErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errThird := errors.New("third")
+
+	sinks := []*fakeSink{{err: errFirst}, {}, {err: errThird}}
+	multi := NewMultiSink(sinks[0], sinks[1], sinks[2])
+
+	err := multi.Message(models.Message{Topic: "orders", Value: []byte(`{}`)}, DecodeStats{})
+	if !errors.Is(err, errFirst) || !errors.Is(err, errThird) {
+		t.Errorf("Message() error = %v, want both sink errors", err)
+	}
+
+	for i, sink := range sinks {
+		if len(sink.messages) != 1 {
+			t.Errorf("sink %d got %d messages, every sink should get the message", i, len(sink.messages))
+		}
+	}
+
+	err = NewMultiSink(sinks[1]).Message(models.Message{Topic: "orders", Value: []byte(`{}`)}, DecodeStats{})
+	if err != nil {
+		t.Errorf("Message() error = %v, want nil when no sink fails", err)
+	}
+
+	multi.Log("orders", "started")
+	multi.Error("orders", errFirst)
+
+	for i, sink := range sinks {
+		if len(sink.logs) != 1 || len(sink.errors) != 1 {
+			t.Errorf("sink %d got %d logs and %d errors, want 1 of each", i, len(sink.logs), len(sink.errors))
+		}
+	}
+}
+
+func TestFileSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+
+	for _, offset := range []int64{1, 2} {
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = sink.Message(models.Message{Topic: "orders", Offset: offset, Value: []byte(`{"id":1}`)}, DecodeStats{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = sink.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	offsets := make([]int64, 0)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record WriterRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			t.Fatalf("line %q is not a record: %v", scanner.Text(), err)
+		}
+
+		offsets = append(offsets, record.Offset)
+	}
+
+	if len(offsets) != 2 || offsets[0] != 1 || offsets[1] != 2 {
+		t.Errorf("file has the offsets %v, want [1 2]", offsets)
+	}
+}
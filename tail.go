@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/krogertechnology/data-tracker/models"
+	"github.com/krogertechnology/data-tracker/service"
+	"github.com/krogertechnology/data-tracker/utils"
+)
+
+// stringList collects a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, " AND ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// tailFilter is the parsed --filter flags, every term has to match.
+type tailFilter struct {
+	text []string
+	json []utils.JSONPredicate
+}
+
+func parseTailFilter(terms []string) (tailFilter, error) {
+	var filter tailFilter
+
+	for _, term := range terms {
+		if !utils.IsJSONPredicate(term) {
+			filter.text = append(filter.text, strings.ToLower(term))
+			continue
+		}
+
+		predicate, err := utils.ParseJSONPredicate(term)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.json = append(filter.json, predicate)
+	}
+
+	return filter, nil
+}
+
+// Match reports whether the decoded message passes the filter, messages that couldn't be decoded only pass an empty filter.
+func (f tailFilter) Match(message models.Message) bool {
+	if len(f.text) == 0 && len(f.json) == 0 {
+		return true
+	}
+
+	if message.DecodeError != "" {
+		return false
+	}
+
+	text := strings.ToLower(string(message.Value))
+	for _, term := range f.text {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+
+	if len(f.json) == 0 {
+		return true
+	}
+
+	var document interface{}
+	if json.Unmarshal(message.Value, &document) != nil {
+		return false
+	}
+
+	for _, predicate := range f.json {
+		if !predicate.Match(document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tailSink writes the messages matching the filter until maxMessages were written.
+type tailSink struct {
+	writer      service.MessageSink
+	filter      tailFilter
+	maxMessages int // 0 means no limit
+	done        context.CancelFunc
+
+	mu      sync.Mutex
+	printed int
+}
+
+func (t *tailSink) Message(message models.Message, stats service.DecodeStats) error {
+	if !t.filter.Match(message) {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.maxMessages > 0 && t.printed >= t.maxMessages {
+		return nil
+	}
+
+	err := t.writer.Message(message, stats)
+	if err != nil {
+		return err
+	}
+
+	t.printed += 1
+	if t.maxMessages > 0 && t.printed >= t.maxMessages {
+		t.done()
+	}
+
+	return nil
+}
+
+func (t *tailSink) Log(topic, text string) {
+	t.writer.Log(topic, text)
+}
+
+func (t *tailSink) Error(topic string, err error) {
+	t.writer.Error(topic, err)
+}
+
+// runTail streams the decoded messages of a connection to stdout without starting the GUI
+// eg: data-tracker tail --config connections.json --from LATEST-10 --filter "$.order.storeId = '0042'"
+func runTail(args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ExitOnError)
+
+	configPath := flags.String("config", "", "JSON file with the connections, the same format as the GUI import")
+	connection := flags.Int("connection", 0, "Index of the connection to tail when the config file has several")
+	hosts := flags.String("brokers", "", "Comma separated bootstrap brokers, overrides KAFKA_HOSTS")
+	topic := flags.String("topic", "", "Comma separated topics, overrides KAFKA_TOPIC")
+	group := flags.String("group", "", "Consumer group ID, overrides KAFKA_CONSUMER_GROUP_ID")
+	mechanism := flags.String("sasl-mechanism", "", "PLAIN, OUTHBEARER, SCRAM-SHA-256, SCRAM-SHA-512, GSSAPI or PLAINTEXT")
+	user := flags.String("sasl-user", "", "SASL username, overrides KAFKA_SASL_USERNAME")
+	connectionString := flags.String("connection-string", "", "Event Hubs connection string, read from DATA_TRACKER_CONNECTION_STRING when empty")
+	format := flags.String("format", "", "Data format, overrides DATA_FORMAT eg: AUTO, JSON, AVRO")
+	compression := flags.String("compression", "", "Payload compression, overrides COMPRESSION eg: AUTO, GZIP")
+	from := flags.String("from", "", "Where to start: OLDEST, LATEST, an offset, LATEST-N or a timestamp, overrides KAFKA_CONSUMER_OFFSET")
+	partitions := flags.String("partitions", "", "Partitions to consume eg: 0,3,7-9, only for a single topic")
+	maxMessages := flags.Int("max-messages", 0, "Stop after printing this many messages, 0 tails until interrupted")
+	output := flags.String("output", "pretty", "Output format: pretty or jsonl")
+	outPath := flags.String("out", "", "Also append the printed messages to this file as JSON Lines")
+
+	var filters stringList
+	flags.Var(&filters, "filter", "Only print the messages matching a JSON path predicate eg: \"$.order.storeId = '0042'\"\nor containing a text, can be repeated")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s tail [flags]\n\nThe SASL password is read from DATA_TRACKER_SASL_PASSWORD.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if *output != "pretty" && *output != "jsonl" {
+		return fmt.Errorf("unknown output %s, expected pretty or jsonl", *output)
+	}
+
+	filter, err := parseTailFilter(filters)
+	if err != nil {
+		return err
+	}
+
+	var writer service.MessageSink = service.NewWriterSink(os.Stdout, os.Stderr, *output == "pretty")
+
+	if *outPath != "" {
+		fileSink, err := service.NewFileSink(*outPath)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %v", *outPath, err)
+		}
+
+		defer fileSink.Close()
+
+		writer = service.NewMultiSink(writer, fileSink)
+	}
+
+	config := models.Config{}
+
+	if *configPath != "" {
+		configs, err := utils.ProcessJSONFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", *configPath, err)
+		}
+
+		if *connection < 0 || *connection >= len(configs) {
+			return fmt.Errorf("connection %d does not exist, %s has %d connections", *connection, *configPath, len(configs))
+		}
+
+		config = configs[*connection]
+	}
+
+	overrides := map[*string]string{
+		&config.KAFKA_HOSTS:             *hosts,
+		&config.KAFKA_TOPIC:             *topic,
+		&config.KAFKA_CONSUMER_GROUP_ID: *group,
+		&config.KAFKA_SASL_MECHANISM:    *mechanism,
+		&config.KAFKA_SASL_USER:         *user,
+		&config.KAFKA_SASL_PASS:         os.Getenv("DATA_TRACKER_SASL_PASSWORD"),
+		&config.CONNECTION_STRING:       *connectionString,
+		&config.DATA_FORMAT:             *format,
+		&config.COMPRESSION:             *compression,
+		&config.KAFKA_CONSUMER_OFFSET:   *from,
+		&config.KAFKA_PARTITIONS:        *partitions,
+	}
+
+	if *connectionString == "" {
+		overrides[&config.CONNECTION_STRING] = os.Getenv("DATA_TRACKER_CONNECTION_STRING")
+	}
+
+	for field, value := range overrides {
+		if value != "" {
+			*field = value
+		}
+	}
+
+	err = utils.ApplyConnectionString(&config)
+	if err != nil {
+		return err
+	}
+
+	if config.KAFKA_HOSTS == "" || config.KAFKA_TOPIC == "" {
+		return errors.New("the brokers and topic are required, set them in --config or with --brokers and --topic")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	kafkaOBJ := service.NewKafkaObj(config)
+
+	client, err := kafkaOBJ.SetupEventhub()
+	if err != nil {
+		return err
+	}
+
+	for _, topic := range kafkaOBJ.Configs.TOPICS {
+		kafkaOBJ.DataChannel[topic] = make(chan models.Message)
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- kafkaOBJ.Read(client)
+		cancel()
+	}()
+
+	// Disconnecting closes the data channels which ends Listen
+	go func() {
+		<-ctx.Done()
+		kafkaOBJ.Disconnect()
+	}()
+
+	sink := &tailSink{
+		writer:      writer,
+		filter:      filter,
+		maxMessages: *maxMessages,
+		done:        cancel,
+	}
+
+	sinks := make(map[string]service.MessageSink, 0)
+	for topic := range kafkaOBJ.DataChannel {
+		sinks[topic] = sink
+	}
+
+	kafkaOBJ.Listen(sinks)
+
+	return <-readErr
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+// EventHubConnectionString is a parsed Event Hubs connection string
+// eg: Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=...;SharedAccessKey=...;EntityPath=topic
+type EventHubConnectionString struct {
+	Raw                 string
+	Namespace           string // eg: ns.servicebus.windows.net
+	SharedAccessKeyName string
+	SharedAccessKey     string
+	EntityPath          string // Empty for a namespace level connection string
+}
+
+// Host is the Kafka endpoint of the namespace.
+func (c EventHubConnectionString) Host() string {
+	return c.Namespace + ":9093"
+}
+
+func ParseEventHubConnectionString(s string) (EventHubConnectionString, error) {
+	connStr := EventHubConnectionString{Raw: strings.TrimSpace(s)}
+
+	var endpoint string
+	for _, part := range strings.Split(connStr.Raw, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return connStr, fmt.Errorf("invalid connection string, %q is not a key=value pair", part)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "endpoint":
+			endpoint = strings.TrimSpace(value)
+		case "sharedaccesskeyname":
+			connStr.SharedAccessKeyName = strings.TrimSpace(value)
+		case "sharedaccesskey":
+			connStr.SharedAccessKey = strings.TrimSpace(value)
+		case "entitypath":
+			connStr.EntityPath = strings.TrimSpace(value)
+		}
+	}
+
+	if endpoint == "" {
+		return connStr, errors.New("invalid connection string, Endpoint is missing")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "sb" || u.Hostname() == "" {
+		return connStr, fmt.Errorf("invalid connection string, expected Endpoint=sb://<namespace>.servicebus.windows.net/ got %q", endpoint)
+	}
+
+	if connStr.SharedAccessKeyName == "" || connStr.SharedAccessKey == "" {
+		return connStr, errors.New("invalid connection string, SharedAccessKeyName and SharedAccessKey are required")
+	}
+
+	connStr.Namespace = u.Hostname()
+
+	return connStr, nil
+}
+
+// ApplyConnectionString fills the SASL/PLAIN settings of the config from its CONNECTION_STRING,
+// the hosts and topic are only derived when they are not set.
+func ApplyConnectionString(config *models.Config) error {
+	if config.CONNECTION_STRING == "" {
+		return nil
+	}
+
+	connStr, err := ParseEventHubConnectionString(config.CONNECTION_STRING)
+	if err != nil {
+		return err
+	}
+
+	if config.KAFKA_HOSTS == "" {
+		config.KAFKA_HOSTS = connStr.Host()
+	}
+
+	if config.KAFKA_TOPIC == "" {
+		if connStr.EntityPath == "" {
+			return errors.New("KAFKA_TOPIC is required when the connection string has no EntityPath")
+		}
+
+		config.KAFKA_TOPIC = connStr.EntityPath
+	}
+
+	if config.KAFKA_SASL_MECHANISM == "" {
+		config.KAFKA_SASL_MECHANISM = "PLAIN"
+	}
+
+	config.KAFKA_SASL_USER = "$ConnectionString"
+	config.KAFKA_SASL_PASS = connStr.Raw
+
+	return nil
+}
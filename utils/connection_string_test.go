@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/krogertechnology/data-tracker/models"
+)
+
+func TestParseEventHubConnectionString(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    EventHubConnectionString
+		wantErr bool
+	}{
+		{
+			input: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=listen;SharedAccessKey=abc+/def=",
+			want:  EventHubConnectionString{Namespace: "ns.servicebus.windows.net", SharedAccessKeyName: "listen", SharedAccessKey: "abc+/def="},
+		},
+		{
+			input: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=listen;SharedAccessKey=key;EntityPath=orders",
+			want:  EventHubConnectionString{Namespace: "ns.servicebus.windows.net", SharedAccessKeyName: "listen", SharedAccessKey: "key", EntityPath: "orders"},
+		},
+		{
+			input: "entitypath=orders;sharedaccesskey=key;sharedaccesskeyname=listen;endpoint=sb://ns.servicebus.windows.net",
+			want:  EventHubConnectionString{Namespace: "ns.servicebus.windows.net", SharedAccessKeyName: "listen", SharedAccessKey: "key", EntityPath: "orders"},
+		},
+		{
+			input: "  Endpoint = sb://ns.servicebus.windows.net/ ;; SharedAccessKeyName=listen ; SharedAccessKey=key;EntityPath=orders;\n",
+			want:  EventHubConnectionString{Namespace: "ns.servicebus.windows.net", SharedAccessKeyName: "listen", SharedAccessKey: "key", EntityPath: "orders"},
+		},
+		{input: "SharedAccessKeyName=listen;SharedAccessKey=key;EntityPath=orders", wantErr: true},
+		{input: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=listen", wantErr: true},
+		{input: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKey=key", wantErr: true},
+		{input: "Endpoint=https://ns.servicebus.windows.net/;SharedAccessKeyName=listen;SharedAccessKey=key", wantErr: true},
+		{input: "Endpoint=sb://ns.servicebus.windows.net/;listen;SharedAccessKey=key", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEventHubConnectionString(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEventHubConnectionString(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		// Raw is checked by TestApplyConnectionString as the SASL password
+		got.Raw = ""
+		if got != tt.want {
+			t.Errorf("ParseEventHubConnectionString(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConnectionString(t *testing.T) {
+	connStr := "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=listen;SharedAccessKey=key"
+
+	tests := []struct {
+		config  models.Config
+		want    models.Config
+		wantErr bool
+	}{
+		{config: models.Config{}, want: models.Config{}},
+		{
+			config: models.Config{CONNECTION_STRING: connStr + ";EntityPath=orders"},
+			want:   models.Config{KAFKA_HOSTS: "ns.servicebus.windows.net:9093", KAFKA_TOPIC: "orders", KAFKA_SASL_MECHANISM: "PLAIN", KAFKA_SASL_USER: "$ConnectionString", KAFKA_SASL_PASS: connStr + ";EntityPath=orders"},
+		},
+		{
+			config: models.Config{CONNECTION_STRING: connStr, KAFKA_HOSTS: "other:9093", KAFKA_TOPIC: "payments"},
+			want:   models.Config{KAFKA_HOSTS: "other:9093", KAFKA_TOPIC: "payments", KAFKA_SASL_MECHANISM: "PLAIN", KAFKA_SASL_USER: "$ConnectionString", KAFKA_SASL_PASS: connStr},
+		},
+		{config: models.Config{CONNECTION_STRING: connStr}, wantErr: true},
+		{config: models.Config{CONNECTION_STRING: "SharedAccessKey=key"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		config := tt.config
+		err := ApplyConnectionString(&config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ApplyConnectionString(%q) error = %v, wantErr %v", tt.config.CONNECTION_STRING, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		if config.KAFKA_HOSTS != tt.want.KAFKA_HOSTS || config.KAFKA_TOPIC != tt.want.KAFKA_TOPIC || config.KAFKA_SASL_MECHANISM != tt.want.KAFKA_SASL_MECHANISM ||
+			config.KAFKA_SASL_USER != tt.want.KAFKA_SASL_USER || config.KAFKA_SASL_PASS != tt.want.KAFKA_SASL_PASS {
+			t.Errorf("ApplyConnectionString(%q) = %+v, want %+v", tt.config.CONNECTION_STRING, config, tt.want)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 func FormatJSONString(s []byte) ([]byte, error) {
-	var jsonData map[string]interface{}
+	var jsonData interface{}
 
 	if err := json.Unmarshal(s, &jsonData); err != nil {
 		return nil, err
@@ -34,3 +37,66 @@ func GetElementsFromString(s string) []string {
 
 	return elements
 }
+
+// maxPartitions bounds the partition numbers of a list so a typo can't expand into millions of partitions.
+const maxPartitions = 10000
+
+// ParsePartitions parses a partition list such as 0,3,7-9. An empty list selects all partitions.
+func ParsePartitions(s string) ([]int32, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	seen := make(map[int32]bool, 0)
+	partitions := make([]int32, 0)
+
+	for _, element := range GetElementsFromString(s) {
+		from, to, isRange := strings.Cut(element, "-")
+
+		start, err := strconv.ParseInt(strings.TrimSpace(from), 10, 32)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid partition: %q", element)
+		}
+
+		end := start
+		if isRange {
+			end, err = strconv.ParseInt(strings.TrimSpace(to), 10, 32)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid partition range: %q", element)
+			}
+		}
+
+		if end >= maxPartitions {
+			return nil, fmt.Errorf("invalid partition: %q, partitions go up to %d", element, maxPartitions-1)
+		}
+
+		for p := start; p <= end; p++ {
+			if !seen[int32(p)] {
+				seen[int32(p)] = true
+				partitions = append(partitions, int32(p))
+			}
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	return partitions, nil
+}
+
+// NormalizeSASLMechanism maps the names used by the form and the JSON import to
+// the mechanism names understood by the kafka datastore.
+func NormalizeSASLMechanism(mechanism string) string {
+	m := strings.ToUpper(strings.TrimSpace(mechanism))
+	m = strings.TrimPrefix(m, "SASL/")
+
+	switch m {
+	case "OAUTHBEARER":
+		return "OUTHBEARER"
+	case "SCRAM-SHA256", "SCRAM_SHA_256":
+		return "SCRAM-SHA-256"
+	case "SCRAM-SHA512", "SCRAM_SHA_512":
+		return "SCRAM-SHA-512"
+	}
+
+	return m
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePartitions(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int32
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: " ", want: nil},
+		{input: "3", want: []int32{3}},
+		{input: "0,3,7-9", want: []int32{0, 3, 7, 8, 9}},
+		{input: "9-7", wantErr: true},
+		{input: "7-9, 8, 0", want: []int32{0, 7, 8, 9}},
+		{input: "9999", want: []int32{9999}},
+		{input: "10000", wantErr: true},
+		{input: "0-2147483647", wantErr: true},
+		{input: "0-100000000", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "a", wantErr: true},
+		{input: "1-b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePartitions(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePartitions(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePartitions(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
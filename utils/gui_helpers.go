@@ -2,6 +2,9 @@ package utils
 
 import (
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -29,3 +32,36 @@ func CreateEntryWidget(placeHolder string, enabled bool, protected bool) *widget
 
 	return e
 }
+
+// CreateFileEntryWidget adds a browse button that fills the entry with the path of the chosen file.
+func CreateFileEntryWidget(entry *widget.Entry, window fyne.Window) *fyne.Container {
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		if entry.Disabled() {
+			return
+		}
+
+		fileDialog := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil || r == nil {
+				return
+			}
+
+			defer r.Close()
+
+			entry.SetText(r.URI().Path())
+		}, window)
+
+		fileDialog.Show()
+	})
+
+	return container.NewBorder(nil, nil, nil, browseButton, entry)
+}
+
+func SetEnabled(enabled bool, widgets ...fyne.Disableable) {
+	for _, w := range widgets {
+		if enabled {
+			w.Enable()
+		} else {
+			w.Disable()
+		}
+	}
+}
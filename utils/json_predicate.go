@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JSONPredicate is a comparison against a JSON path of the message
+// eg: $.order.storeId = '0042'
+type JSONPredicate struct {
+	Path     string
+	Operator string
+	Value    interface{} // string, float64, bool or nil
+
+	like *regexp.Regexp // Compiled LIKE pattern
+}
+
+var jsonPredicateRegex = regexp.MustCompile(`^(\$[^\s=!<>]*)\s*(=|!=|<>|>=|<=|>|<|(?i:like)\s)\s*(.+)$`)
+
+func IsJSONPredicate(expr string) bool {
+	return strings.HasPrefix(strings.TrimSpace(expr), "$")
+}
+
+func ParseJSONPredicate(expr string) (JSONPredicate, error) {
+	matches := jsonPredicateRegex.FindStringSubmatch(strings.TrimSpace(expr))
+	if matches == nil {
+		return JSONPredicate{}, fmt.Errorf("invalid JSON predicate %q, expected eg: $.order.storeId = '0042'", expr)
+	}
+
+	predicate := JSONPredicate{
+		Path:     matches[1],
+		Operator: strings.ToUpper(strings.TrimSpace(matches[2])),
+	}
+
+	if predicate.Operator == "<>" {
+		predicate.Operator = "!="
+	}
+
+	value, err := parseJSONLiteral(strings.TrimSpace(matches[3]))
+	if err != nil {
+		return JSONPredicate{}, fmt.Errorf("invalid value in JSON predicate %q: %v", expr, err)
+	}
+
+	if value == nil && predicate.Operator != "=" && predicate.Operator != "!=" {
+		return JSONPredicate{}, fmt.Errorf("null can only be compared with = or != in %q", expr)
+	}
+
+	predicate.Value = value
+
+	if predicate.Operator == "LIKE" {
+		pattern, ok := value.(string)
+		if !ok {
+			return JSONPredicate{}, fmt.Errorf("LIKE needs a quoted pattern in %q", expr)
+		}
+
+		predicate.like = likeRegexp(pattern)
+	}
+
+	return predicate, nil
+}
+
+func parseJSONLiteral(s string) (interface{}, error) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], nil
+	}
+
+	switch strings.ToLower(s) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a quoted string, number, boolean or null", s)
+	}
+
+	return number, nil
+}
+
+// Match evaluates the predicate against a decoded JSON document, a value only compares with one of
+// the same type, a missing value only matches = null and LIKE only matches strings, ignoring their case.
+func (p JSONPredicate) Match(document interface{}) bool {
+	value, ok := extractJSONPath(document, p.Path)
+	if !ok {
+		value = nil
+	}
+
+	if p.Value == nil {
+		return (value == nil) == (p.Operator == "=")
+	}
+
+	if value == nil {
+		return false
+	}
+
+	if p.Operator == "LIKE" {
+		text, ok := value.(string)
+		return ok && p.like != nil && p.like.MatchString(text)
+	}
+
+	var cmp int
+	switch left := value.(type) {
+	case float64:
+		right, ok := p.Value.(float64)
+		if !ok {
+			return p.Operator == "!="
+		}
+
+		switch {
+		case left < right:
+			cmp = -1
+		case left > right:
+			cmp = 1
+		}
+
+	case string:
+		right, ok := p.Value.(string)
+		if !ok {
+			return p.Operator == "!="
+		}
+
+		cmp = strings.Compare(left, right)
+
+	case bool:
+		if p.Operator != "=" && p.Operator != "!=" {
+			return false
+		}
+
+		return (left == p.Value) == (p.Operator == "=")
+
+	default:
+		// Objects and arrays
+		return p.Operator == "!="
+	}
+
+	switch p.Operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+
+	return false
+}
+
+// extractJSONPath follows a path such as $.order.items[0]."store id" through a decoded JSON document.
+func extractJSONPath(document interface{}, path string) (interface{}, bool) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, false
+	}
+
+	current := document
+	rest := path[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+
+			var key string
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.Index(rest[1:], `"`)
+				if end < 0 {
+					return nil, false
+				}
+
+				key, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ".[")
+				if end < 0 {
+					end = len(rest)
+				}
+
+				key, rest = rest[:end], rest[end:]
+			}
+
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			current, ok = object[key]
+			if !ok {
+				return nil, false
+			}
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, false
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			rest = rest[end+1:]
+
+			array, ok := current.([]interface{})
+			if err != nil || !ok || index < 0 || index >= len(array) {
+				return nil, false
+			}
+
+			current = array[index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// likeRegexp compiles a SQL LIKE pattern with % and _ wildcards into a case insensitive regexp.
+func likeRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONPredicateMatch(t *testing.T) {
+	document := `{"order":{"storeId":"0042","total":12.5,"items":[{"sku":"Éclair"}],"paid":true,"note":null}}`
+
+	var decoded interface{}
+	err := json.Unmarshal([]byte(document), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "$.order.storeId = '0042'", want: true},
+		{expr: "$.order.storeId = 42", want: false},
+		{expr: "$.order.storeId != 42", want: true},
+		{expr: "$.order.storeId <> '0042'", want: false},
+		{expr: "$.order.storeId < '1'", want: true},
+		{expr: "$.order.storeId > 10", want: false},
+		{expr: "$.order.storeId LIKE '00%'", want: true},
+		{expr: "$.order.storeId LIKE '00_'", want: false},
+		{expr: "$.order.total >= 12.5", want: true},
+		{expr: "$.order.total < 12", want: false},
+		{expr: "$.order.total = '12.5'", want: false},
+		{expr: "$.order.total LIKE '12%'", want: false},
+		{expr: "$.order.paid = true", want: true},
+		{expr: "$.order.paid != false", want: true},
+		{expr: "$.order.paid > false", want: false},
+		{expr: "$.order.items[0].sku like 'éCLAIR'", want: true},
+		{expr: "$.order.items[0].sku LIKE 'É%r'", want: true},
+		{expr: "$.order.items[1].sku = 'x'", want: false},
+		{expr: "$.order.items = 'x'", want: false},
+		{expr: "$.order.items != 'x'", want: true},
+		{expr: "$.order.note = null", want: true},
+		{expr: "$.order.missing = null", want: true},
+		{expr: "$.order.storeId != null", want: true},
+		{expr: "$.order.missing != 'x'", want: false},
+		{expr: "$.order.missing < 1", want: false},
+	}
+
+	for _, tt := range tests {
+		predicate, err := ParseJSONPredicate(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := predicate.Match(decoded); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONPredicate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    JSONPredicate
+		wantErr bool
+	}{
+		{input: "$.a = 'x'", want: JSONPredicate{Path: "$.a", Operator: "=", Value: "x"}},
+		{input: `$.a<>"x"`, want: JSONPredicate{Path: "$.a", Operator: "!=", Value: "x"}},
+		{input: "$.a >= -1.5", want: JSONPredicate{Path: "$.a", Operator: ">=", Value: -1.5}},
+		{input: "$.a = TRUE", want: JSONPredicate{Path: "$.a", Operator: "=", Value: true}},
+		{input: "$.a != null", want: JSONPredicate{Path: "$.a", Operator: "!=", Value: nil}},
+		{input: "$.a > null", wantErr: true},
+		{input: "$.a = x", wantErr: true},
+		{input: "$.a LIKE 4", wantErr: true},
+		{input: "$.a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseJSONPredicate(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJSONPredicate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && (got.Path != tt.want.Path || got.Operator != tt.want.Operator || got.Value != tt.want.Value) {
+			t.Errorf("ParseJSONPredicate(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}